@@ -0,0 +1,210 @@
+package exporter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// acllog-max-len defaults to 128, ACL LOG without a count only returns the 10 most recent entries
+const aclLogMaxEntries = 128
+
+type aclLogKey struct {
+	reason     string
+	username   string
+	clientName string
+}
+
+// aclLogState keeps the count of every entry of the ACL log seen during the last scrape, entries are
+// grouped by redis (their count is incremented) so only the increase since the last scrape is added to the counters.
+type aclLogState struct {
+	seen     map[string]aclLogEntry
+	counters map[aclLogKey]float64
+}
+
+type aclLogEntry struct {
+	id    string
+	key   aclLogKey
+	count float64
+}
+
+// aclUser is a user of ACL LIST, e.g. "user default on nopass sanitize-payload ~* &* +@all"
+type aclUser struct {
+	name              string
+	enabled           bool
+	nopass            bool
+	rules             int
+	allowedCategories []string
+}
+
+func (e *Exporter) extractAclMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	if e.options.ExcludeAclLogCounters {
+		log.Debugf("extractAclMetrics() skipping the ACL LOG counters")
+	} else if reply, err := redis.Values(doRedisCmd(c, "ACL", "LOG", aclLogMaxEntries)); err != nil {
+		log.Errorf("extractAclMetrics() ACL LOG err: %s", err)
+	} else {
+		e.updateAclLog(parseAclLog(reply))
+		for k, cnt := range e.aclLog.counters {
+			e.registerConstMetric(ch, "acl_log_entries_total", cnt, prometheus.CounterValue, k.reason, k.username, k.clientName)
+		}
+	}
+
+	users, err := redis.Strings(doRedisCmd(c, "ACL", "LIST"))
+	if err != nil {
+		log.Errorf("extractAclMetrics() ACL LIST err: %s", err)
+		return
+	}
+	for _, line := range users {
+		u, ok := parseAclUser(line)
+		if !ok {
+			log.Debugf("extractAclMetrics() couldn't parse ACL LIST line: %s", line)
+			continue
+		}
+		e.registerConstMetricGauge(ch, "acl_user_enabled", boolToFloat(u.enabled), u.name)
+		e.registerConstMetricGauge(ch, "acl_user_nopass", boolToFloat(u.nopass), u.name)
+		e.registerConstMetricGauge(ch, "acl_user_rules", float64(u.rules), u.name)
+		for _, category := range u.allowedCategories {
+			e.registerConstMetricGauge(ch, "acl_user_allowed_category", 1, u.name, category)
+		}
+	}
+}
+
+func (e *Exporter) updateAclLog(entries []aclLogEntry) {
+	if e.aclLog == nil {
+		e.aclLog = &aclLogState{seen: map[string]aclLogEntry{}, counters: map[aclLogKey]float64{}}
+	}
+
+	seen := make(map[string]aclLogEntry, len(entries))
+	for _, entry := range entries {
+		increase := entry.count
+		if prev, ok := e.aclLog.seen[entry.id]; ok && prev.count <= entry.count {
+			increase = entry.count - prev.count
+			// redis updates the client-info of an entry to the last client, the entry stays counted for the first one
+			entry.key = prev.key
+		}
+		e.aclLog.counters[entry.key] += increase
+		seen[entry.id] = entry
+	}
+	// entries that aren't returned anymore were evicted or removed by ACL LOG RESET
+	e.aclLog.seen = seen
+}
+
+/*
+parseAclLog parses the reply of ACL LOG, every entry is a list of field/value pairs:
+count, reason, context, object, username, age-seconds, client-info and, since Redis 7.2,
+entry-id, timestamp-created and timestamp-last-updated.
+*/
+func parseAclLog(reply []any) []aclLogEntry {
+	var entries []aclLogEntry
+	for _, r := range reply {
+		values, err := redis.Values(r, nil)
+		if err != nil {
+			log.Debugf("parseAclLog() couldn't parse entry: %v, err: %s", r, err)
+			continue
+		}
+		// count and the ids/timestamps are integers, the other fields bulk strings
+		fields := make(map[string]string, len(values)/2)
+		for i := 0; i+1 < len(values); i += 2 {
+			field, _ := redis.String(values[i], nil)
+			if v, ok := values[i+1].(int64); ok {
+				fields[field] = strconv.FormatInt(v, 10)
+			} else {
+				fields[field], _ = redis.String(values[i+1], nil)
+			}
+		}
+		count, err := strconv.ParseFloat(fields["count"], 64)
+		if err != nil {
+			continue
+		}
+
+		entry := aclLogEntry{
+			count: count,
+			key: aclLogKey{
+				reason:     fields["reason"],
+				username:   fields["username"],
+				clientName: clientInfoField(fields["client-info"], "name"),
+			},
+		}
+		if id, ok := fields["entry-id"]; ok {
+			// entry ids start over after a restart, the creation timestamp tells the entries apart
+			entry.id = id + "/" + fields["timestamp-created"]
+		} else {
+			// before Redis 7.2 entries are identified by the fields redis groups them by, client-info is
+			// overwritten by the last client so it's not part of it
+			entry.id = strings.Join([]string{fields["reason"], fields["context"], fields["object"], fields["username"]}, "\n")
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// clientInfoField returns a field of a CLIENT LIST / CLIENT INFO line, e.g. name of "id=3 addr=127.0.0.1:57208 name=worker age=0"
+func clientInfoField(clientInfo string, field string) string {
+	for kv := range strings.FieldsSeq(clientInfo) {
+		if val, ok := strings.CutPrefix(kv, field+"="); ok {
+			return val
+		}
+	}
+	return ""
+}
+
+func parseAclUser(line string) (aclUser, bool) {
+	rest, ok := strings.CutPrefix(line, "user ")
+	if !ok {
+		return aclUser{}, false
+	}
+	rules := splitAclRules(rest)
+	if len(rules) == 0 {
+		return aclUser{}, false
+	}
+
+	u := aclUser{name: rules[0], rules: len(rules) - 1}
+	for _, rule := range rules[1:] {
+		switch {
+		case rule == "on":
+			u.enabled = true
+		case rule == "nopass":
+			u.nopass = true
+		case strings.HasPrefix(rule, "+@"):
+			u.allowedCategories = append(u.allowedCategories, rule[2:])
+		}
+	}
+	return u, true
+}
+
+// splitAclRules splits the rules of an ACL LIST line by spaces, except for the spaces within the selectors of Redis 7, e.g. "(~key:* +get)"
+func splitAclRules(s string) []string {
+	var rules []string
+	depth, start := 0, -1
+	for i, ch := range s {
+		switch {
+		case ch == '(':
+			depth++
+		case ch == ')' && depth > 0:
+			depth--
+		case ch == ' ' && depth == 0:
+			if start >= 0 {
+				rules = append(rules, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		rules = append(rules, s[start:])
+	}
+	return rules
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
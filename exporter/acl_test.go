@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func testAclLogEntry(count int64, reason string, username string, clientInfo string, entryID int64) []any {
+	entry := []any{
+		[]byte("count"), count,
+		[]byte("reason"), []byte(reason),
+		[]byte("context"), []byte("toplevel"),
+		[]byte("object"), []byte("AUTH"),
+		[]byte("username"), []byte(username),
+		[]byte("age-seconds"), []byte("4.0960000000000001"),
+		[]byte("client-info"), []byte(clientInfo),
+	}
+	if entryID >= 0 {
+		entry = append(entry,
+			[]byte("entry-id"), entryID,
+			[]byte("timestamp-created"), int64(1675361492408),
+			[]byte("timestamp-last-updated"), int64(1675361492408),
+		)
+	}
+	return entry
+}
+
+func TestParseAclUser(t *testing.T) {
+	for _, tst := range []struct {
+		line       string
+		want       aclUser
+		categories string
+	}{
+		{
+			line: "user default on nopass sanitize-payload ~* &* +@all",
+			want: aclUser{name: "default", enabled: true, nopass: true, rules: 6}, categories: "all",
+		},
+		{
+			line: "user worker off #5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8 ~jobs:* resetchannels -@all +@read +@write (~cache:* +get)",
+			want: aclUser{name: "worker", rules: 8}, categories: "read,write",
+		},
+	} {
+		u, ok := parseAclUser(tst.line)
+		if !ok {
+			t.Fatalf("couldn't parse: %s", tst.line)
+		}
+		if u.name != tst.want.name || u.enabled != tst.want.enabled || u.nopass != tst.want.nopass || u.rules != tst.want.rules || strings.Join(u.allowedCategories, ",") != tst.categories {
+			t.Errorf("line %q: unexpected user: %#v", tst.line, u)
+		}
+	}
+
+	if _, ok := parseAclUser("not a user"); ok {
+		t.Errorf("expected an error for an invalid line")
+	}
+}
+
+func TestAclMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclAclMetrics: true})
+
+	var entries []any
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		if args[0] == "LOG" {
+			return entries, nil
+		}
+		return []any{
+			[]byte("user default on nopass sanitize-payload ~* &* +@all"),
+			[]byte("user worker off #5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8 ~jobs:* -@all +@read"),
+		}, nil
+	}}
+
+	collect := func() map[string]float64 {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractAclMetrics(chM, c)
+			close(chM)
+		}()
+
+		got := map[string]float64{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			desc := m.Desc().String()
+			name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+			for _, l := range d.GetLabel() {
+				name += "/" + l.GetValue()
+			}
+			got[name] = d.GetGauge().GetValue() + d.GetCounter().GetValue()
+		}
+		return got
+	}
+
+	entries = []any{
+		testAclLogEntry(3, "auth", "worker", "id=3 addr=127.0.0.1:57275 name=billing age=0", 0),
+		testAclLogEntry(1, "command", "default", "id=4 addr=127.0.0.1:57276 name= age=0", 1),
+	}
+	got := collect()
+	for name, want := range map[string]float64{
+		"test_acl_log_entries_total/billing/auth/worker": 3,
+		"test_acl_log_entries_total//command/default":    1,
+		"test_acl_user_enabled/default":                  1,
+		"test_acl_user_enabled/worker":                   0,
+		"test_acl_user_nopass/default":                   1,
+		"test_acl_user_rules/worker":                     5,
+		"test_acl_user_allowed_category/read/worker":     1,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+
+	// the count of entry 0 was incremented, entry 1 was evicted and a new entry 2 was added
+	entries = []any{
+		testAclLogEntry(5, "auth", "worker", "id=3 addr=127.0.0.1:57275 name=billing age=0", 0),
+		testAclLogEntry(2, "auth", "worker", "id=5 addr=127.0.0.1:57277 name=billing age=0", 2),
+	}
+	got = collect()
+	if v := got["test_acl_log_entries_total/billing/auth/worker"]; v != 7 {
+		t.Errorf("expected 7 auth failures, got %f", v)
+	}
+	if v := got["test_acl_log_entries_total//command/default"]; v != 1 {
+		t.Errorf("expected the counter of the evicted entry to stay, got %f", v)
+	}
+
+	// entries of Redis before 7.2 don't have an entry-id
+	entries = []any{testAclLogEntry(2, "key", "default", "id=6 addr=127.0.0.1:57278 name=cache age=0", -1)}
+	collect()
+	entries = []any{testAclLogEntry(4, "key", "default", "id=6 addr=127.0.0.1:57278 name=cache age=0", -1)}
+	if v := collect()["test_acl_log_entries_total/cache/key/default"]; v != 4 {
+		t.Errorf("expected 4 denied keys, got %f", v)
+	}
+
+	// redis updates the client-info of a grouped entry to the last client, that's still the same entry
+	entries = []any{testAclLogEntry(6, "key", "default", "id=7 addr=127.0.0.1:57279 name=worker age=0", -1)}
+	got = collect()
+	if v := got["test_acl_log_entries_total/cache/key/default"]; v != 6 {
+		t.Errorf("expected 6 denied keys, got %f", v)
+	}
+	if v, ok := got["test_acl_log_entries_total/worker/key/default"]; ok {
+		t.Errorf("expected no denied keys counted for the last client, got %f", v)
+	}
+
+	// the exporters of /scrape requests only export the users
+	e.options.ExcludeAclLogCounters = true
+	got = collect()
+	if v, ok := got["test_acl_log_entries_total/cache/key/default"]; ok {
+		t.Errorf("didn't expect the ACL LOG counters with ExcludeAclLogCounters, got %f", v)
+	}
+	if _, ok := got["test_acl_user_enabled/default"]; !ok {
+		t.Errorf("expected the ACL LIST metrics with ExcludeAclLogCounters")
+	}
+}
@@ -0,0 +1,231 @@
+package exporter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultBigKeysTopN = 10
+
+type topKeyEntry struct {
+	key     string
+	keyType string
+	value   float64
+}
+
+// topKeys keeps the n keys with the highest value, sorted in descending order
+type topKeys struct {
+	n       int
+	entries []topKeyEntry
+}
+
+func newTopKeys(n int) *topKeys {
+	return &topKeys{n: n}
+}
+
+func (t *topKeys) add(entry topKeyEntry) {
+	// SCAN can return a key more than once during a full iteration
+	if idx := slices.IndexFunc(t.entries, func(e topKeyEntry) bool { return e.key == entry.key }); idx >= 0 {
+		t.entries = slices.Delete(t.entries, idx, idx+1)
+	}
+	if len(t.entries) >= t.n && entry.value <= t.entries[len(t.entries)-1].value {
+		return
+	}
+	idx, _ := slices.BinarySearchFunc(t.entries, entry.value, func(e topKeyEntry, v float64) int {
+		// descending order
+		switch {
+		case e.value > v:
+			return -1
+		case e.value < v:
+			return 1
+		}
+		return 0
+	})
+	t.entries = slices.Insert(t.entries, idx, entry)
+	if len(t.entries) > t.n {
+		t.entries = t.entries[:t.n]
+	}
+}
+
+// bigKeysScan is the state of the SCAN over all databases, it's resumed with every scrape
+// until the pass over the whole keyspace is complete and the top keys get published.
+type bigKeysScan struct {
+	db     int
+	cursor string
+
+	memoryUsage map[int]*topKeys
+	frequency   map[int]*topKeys
+
+	publishedMemoryUsage map[int]*topKeys
+	publishedFrequency   map[int]*topKeys
+
+	passes      float64
+	scannedKeys float64
+}
+
+func newBigKeysScan() *bigKeysScan {
+	return &bigKeysScan{
+		cursor:      "0",
+		memoryUsage: map[int]*topKeys{},
+		frequency:   map[int]*topKeys{},
+	}
+}
+
+func maxmemoryPolicyIsLFU(info string) bool {
+	for line := range strings.SplitSeq(info, "\n") {
+		line = strings.TrimSpace(line)
+		if policy, ok := strings.CutPrefix(line, "maxmemory_policy:"); ok {
+			return strings.HasSuffix(policy, "-lfu")
+		}
+	}
+	return false
+}
+
+func (e *Exporter) extractBigKeysMetrics(ch chan<- prometheus.Metric, c redis.Conn, dbCount int, lfu bool) {
+	if e.options.CheckBigKeysBudget <= 0 {
+		return
+	}
+	if e.bigKeys == nil {
+		e.bigKeys = newBigKeysScan()
+	}
+
+	start := time.Now()
+	if err := e.scanBigKeys(c, dbCount, lfu); err != nil {
+		log.Errorf("scanBigKeys() err: %s", err)
+	}
+	log.Debugf("scanBigKeys() took %s, db: %d cursor: %s", time.Since(start), e.bigKeys.db, e.bigKeys.cursor)
+
+	// until the first pass is complete, export what has been found so far
+	memoryUsage, frequency := e.bigKeys.publishedMemoryUsage, e.bigKeys.publishedFrequency
+	if memoryUsage == nil {
+		memoryUsage, frequency = e.bigKeys.memoryUsage, e.bigKeys.frequency
+	}
+
+	for db, top := range memoryUsage {
+		for _, entry := range top.entries {
+			e.registerConstMetricGauge(ch, "big_key_memory_usage_bytes", entry.value, fmt.Sprintf("db%d", db), entry.key, entry.keyType)
+		}
+	}
+	for db, top := range frequency {
+		for _, entry := range top.entries {
+			e.registerConstMetricGauge(ch, "hot_key_lfu_frequency", entry.value, fmt.Sprintf("db%d", db), entry.key, entry.keyType)
+		}
+	}
+
+	e.registerConstMetric(ch, "big_keys_scan_passes_total", e.bigKeys.passes, prometheus.CounterValue)
+	e.registerConstMetric(ch, "big_keys_scanned_keys_total", e.bigKeys.scannedKeys, prometheus.CounterValue)
+}
+
+// scanBigKeys resumes the SCAN where the previous scrape stopped and examines up to
+// CheckBigKeysBudget keys, in batches of CheckKeysBatchSize.
+func (e *Exporter) scanBigKeys(c redis.Conn, dbCount int, lfu bool) error {
+	s := e.bigKeys
+	if s.db >= dbCount {
+		s.db, s.cursor = 0, "0"
+	}
+
+	if !e.options.IsCluster {
+		if _, err := doRedisCmd(c, "SELECT", s.db); err != nil {
+			return err
+		}
+	}
+
+	budget := e.options.CheckBigKeysBudget
+	for budget > 0 {
+		arr, err := redis.Values(doRedisCmd(c, "SCAN", s.cursor, "COUNT", min(budget, e.options.CheckKeysBatchSize)))
+		if err != nil {
+			return err
+		}
+		if len(arr) != 2 {
+			return fmt.Errorf("invalid response from SCAN")
+		}
+		keys, _ := redis.Strings(arr[1], nil)
+		cursor, _ := redis.String(arr[0], nil)
+
+		if err := e.observeBigKeys(c, s.db, keys, lfu); err != nil {
+			return err
+		}
+		budget -= int64(len(keys))
+		s.scannedKeys += float64(len(keys))
+
+		// an empty batch doesn't use up the budget, limit the number of SCAN calls per scrape anyway
+		budget--
+
+		s.cursor = cursor
+		if cursor != "0" {
+			continue
+		}
+
+		s.db++
+		if s.db >= dbCount {
+			s.db = 0
+			s.passes++
+			s.publishedMemoryUsage, s.publishedFrequency = s.memoryUsage, s.frequency
+			s.memoryUsage, s.frequency = map[int]*topKeys{}, map[int]*topKeys{}
+			log.Debugf("big keys scan pass complete, passes: %.0f", s.passes)
+			// don't start the next pass in the same scrape
+			return nil
+		}
+		if !e.options.IsCluster {
+			if _, err := doRedisCmd(c, "SELECT", s.db); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) observeBigKeys(c redis.Conn, db int, keys []string, lfu bool) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := c.Send("TYPE", key); err != nil {
+			return err
+		}
+		if err := c.Send("MEMORY", "USAGE", key); err != nil {
+			return err
+		}
+		if lfu {
+			if err := c.Send("OBJECT", "FREQ", key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Flush(); err != nil {
+		return err
+	}
+
+	topN := int(e.options.CheckBigKeysTopN)
+	if topN <= 0 {
+		topN = defaultBigKeysTopN
+	}
+	if e.bigKeys.memoryUsage[db] == nil {
+		e.bigKeys.memoryUsage[db] = newTopKeys(topN)
+	}
+	if lfu && e.bigKeys.frequency[db] == nil {
+		e.bigKeys.frequency[db] = newTopKeys(topN)
+	}
+
+	for _, key := range keys {
+		keyType, _ := redis.String(c.Receive())
+		usage, usageErr := redis.Int64(c.Receive())
+		// keys deleted after SCAN returned them have type "none" and no memory usage
+		if usageErr == nil && keyType != "none" {
+			e.bigKeys.memoryUsage[db].add(topKeyEntry{key: key, keyType: keyType, value: float64(usage)})
+		}
+		if lfu {
+			if freq, err := redis.Int64(c.Receive()); err == nil && keyType != "none" {
+				e.bigKeys.frequency[db].add(topKeyEntry{key: key, keyType: keyType, value: float64(freq)})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestBigKeysTopKeys(t *testing.T) {
+	top := newTopKeys(3)
+	for _, e := range []topKeyEntry{
+		{key: "a", value: 10},
+		{key: "b", value: 50},
+		{key: "c", value: 30},
+		{key: "d", value: 5},
+		{key: "e", value: 40},
+		// duplicates returned by SCAN replace the previous entry
+		{key: "c", value: 60},
+	} {
+		top.add(e)
+	}
+
+	var got []string
+	for _, e := range top.entries {
+		got = append(got, e.key)
+	}
+	if s := strings.Join(got, ","); s != "c,b,e" {
+		t.Errorf("unexpected top keys: %s", s)
+	}
+}
+
+func TestBigKeysMaxmemoryPolicyIsLFU(t *testing.T) {
+	for policy, want := range map[string]bool{
+		"allkeys-lfu":  true,
+		"volatile-lfu": true,
+		"allkeys-lru":  false,
+		"noeviction":   false,
+	} {
+		if got := maxmemoryPolicyIsLFU("# Memory\r\nmaxmemory:0\r\nmaxmemory_policy:" + policy + "\r\n"); got != want {
+			t.Errorf("policy %s: want %t, got %t", policy, want, got)
+		}
+	}
+}
+
+func TestBigKeysScanResumesAcrossScrapes(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckKeysBatchSize: 2, CheckBigKeysBudget: 2, CheckBigKeysTopN: 2})
+
+	// db0 holds 4 keys returned in two SCAN batches, db1 is empty
+	var scans []string
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch cmd {
+		case "SELECT":
+			return "OK", nil
+		case "SCAN":
+			scans = append(scans, args[0].(string))
+			if args[0] == "0" {
+				return []any{[]byte("5"), []any{[]byte("k1"), []byte("k2")}}, nil
+			}
+			return []any{[]byte("0"), []any{[]byte("k3"), []byte("k4")}}, nil
+		case "TYPE":
+			return "string", nil
+		case "MEMORY":
+			return map[string]int64{"k1": 100, "k2": 400, "k3": 300, "k4": 200}[args[1].(string)], nil
+		case "OBJECT":
+			return map[string]int64{"k1": 9, "k2": 1, "k3": 2, "k4": 3}[args[1].(string)], nil
+		}
+		t.Errorf("unexpected command: %s", cmd)
+		return nil, nil
+	}}
+
+	collect := func() map[string]float64 {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractBigKeysMetrics(chM, c, 1, true)
+			close(chM)
+		}()
+		got := map[string]float64{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			desc := m.Desc().String()
+			name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+			for _, l := range d.GetLabel() {
+				if l.GetName() == "key" {
+					name += "/" + l.GetValue()
+				}
+			}
+			got[name] = d.GetGauge().GetValue() + d.GetCounter().GetValue()
+		}
+		return got
+	}
+
+	// first scrape only gets through the first SCAN batch, partial results are exported
+	got := collect()
+	if got["test_big_key_memory_usage_bytes/k2"] != 400 || got["test_big_keys_scan_passes_total"] != 0 {
+		t.Errorf("unexpected metrics after first scrape: %v", got)
+	}
+
+	// second scrape resumes the cursor and completes the pass
+	got = collect()
+	if len(scans) != 2 || scans[1] != "5" {
+		t.Errorf("expected the second scrape to resume the cursor, scans: %v", scans)
+	}
+	if got["test_big_keys_scan_passes_total"] != 1 || got["test_big_keys_scanned_keys_total"] != 4 {
+		t.Errorf("unexpected scan counters: %v", got)
+	}
+	if got["test_big_key_memory_usage_bytes/k2"] != 400 || got["test_big_key_memory_usage_bytes/k3"] != 300 || len(e.bigKeys.publishedMemoryUsage[0].entries) != 2 {
+		t.Errorf("unexpected big keys: %v", got)
+	}
+	if got["test_hot_key_lfu_frequency/k1"] != 9 || got["test_hot_key_lfu_frequency/k4"] != 3 {
+		t.Errorf("unexpected hot keys: %v", got)
+	}
+
+	// the third scrape starts a new pass, the published results of the previous one are still exported
+	got = collect()
+	if scans[2] != "0" || got["test_big_key_memory_usage_bytes/k3"] != 300 {
+		t.Errorf("unexpected state after third scrape, scans: %v metrics: %v", scans, got)
+	}
+}
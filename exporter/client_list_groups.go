@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// clientListDimension is one of the dimensions of client-list-aggregate-by the clients are grouped by
+type clientListDimension struct {
+	label string
+	value func(info *ClientInfo) string
+}
+
+// descriptions of the client_list_group_* metrics, they are labeled by the dimensions of client-list-aggregate-by
+var clientListGroupMetrics = map[string]string{
+	"client_list_group_clients":                        "Number of connected clients of the group as reported by CLIENT LIST",
+	"client_list_group_query_buffer_length_bytes_sum":  "Sum of the query buffer lengths in bytes of the clients of the group",
+	"client_list_group_query_buffer_length_bytes_max":  "Largest query buffer length in bytes of a client of the group",
+	"client_list_group_output_buffer_memory_bytes_sum": "Sum of the output buffer memory usage in bytes of the clients of the group",
+	"client_list_group_output_buffer_memory_bytes_max": "Largest output buffer memory usage in bytes of a client of the group",
+	"client_list_group_total_memory_bytes_sum":         "Sum of the total memory in bytes consumed by the clients of the group, including their buffers",
+	"client_list_group_total_memory_bytes_max":         "Largest total memory in bytes consumed by a client of the group, including its buffers",
+	"client_list_group_idle_seconds_sum":               "Sum of the idle times in seconds of the clients of the group",
+	"client_list_group_idle_seconds_max":               "Longest idle time in seconds of a client of the group",
+}
+
+type clientListGroup struct {
+	labelValues []string
+	clients     float64
+
+	qbufSum, qbufMax     float64
+	omemSum, omemMax     float64
+	totMemSum, totMemMax float64
+	idleSum, idleMax     float64
+}
+
+func (g *clientListGroup) add(info *ClientInfo, now int64) {
+	idle := float64(max(now-info.IdleSince, 0))
+	g.clients++
+	g.qbufSum += float64(info.Qbuf)
+	g.qbufMax = max(g.qbufMax, float64(info.Qbuf))
+	g.omemSum += float64(info.OMem)
+	g.omemMax = max(g.omemMax, float64(info.OMem))
+	g.totMemSum += float64(info.TotMem)
+	g.totMemMax = max(g.totMemMax, float64(info.TotMem))
+	g.idleSum += idle
+	g.idleMax = max(g.idleMax, idle)
+}
+
+/*
+parseClientListDimensions parses the comma separated dimensions of client-list-aggregate-by:
+name, user, lib-name, lib-ver, flags, db and ip, the source IP can be grouped by network
+with a prefix length, e.g. ip/24 (for IPv6 addresses the prefix length is applied as well)
+*/
+func parseClientListDimensions(arg string) ([]clientListDimension, error) {
+	var dimensions []clientListDimension
+	seen := map[string]bool{}
+	for dim := range strings.SplitSeq(arg, ",") {
+		if dim = strings.TrimSpace(dim); dim == "" {
+			continue
+		}
+
+		var d clientListDimension
+		switch dim {
+		case "name":
+			d = clientListDimension{label: "name", value: func(info *ClientInfo) string { return info.Name }}
+		case "user":
+			d = clientListDimension{label: "user", value: func(info *ClientInfo) string { return info.User }}
+		case "lib-name":
+			d = clientListDimension{label: "lib_name", value: func(info *ClientInfo) string { return info.LibName }}
+		case "lib-ver":
+			d = clientListDimension{label: "lib_ver", value: func(info *ClientInfo) string { return info.LibVer }}
+		case "flags":
+			d = clientListDimension{label: "flags", value: func(info *ClientInfo) string { return info.Flags }}
+		case "db":
+			d = clientListDimension{label: "db", value: func(info *ClientInfo) string { return info.Db }}
+		case "ip":
+			d = clientListDimension{label: "ip", value: func(info *ClientInfo) string { return info.Host }}
+		default:
+			prefix, ok := strings.CutPrefix(dim, "ip/")
+			if !ok {
+				return nil, fmt.Errorf("unknown client list dimension: %s", dim)
+			}
+			bits, err := strconv.Atoi(prefix)
+			if err != nil || bits < 0 || bits > 128 {
+				return nil, fmt.Errorf("invalid prefix length of client list dimension: %s", dim)
+			}
+			d = clientListDimension{label: "ip", value: func(info *ClientInfo) string { return clientNetwork(info.Host, bits) }}
+		}
+
+		if seen[d.label] {
+			return nil, fmt.Errorf("duplicate client list dimension: %s", dim)
+		}
+		seen[d.label] = true
+		dimensions = append(dimensions, d)
+	}
+	return dimensions, nil
+}
+
+// clientNetwork returns the network of the address with the prefix length in CIDR notation, e.g. 10.0.1.0/24
+func clientNetwork(host string, bits int) string {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		// unix sockets
+		return host
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	addrBits := len(ip) * 8
+	bits = min(bits, addrBits)
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(bits, addrBits)), Mask: net.CIDRMask(bits, addrBits)}).String()
+}
+
+func (e *Exporter) parseClientListGroupMetrics(input string, ch chan<- prometheus.Metric) {
+	now := time.Now().Unix()
+	groups := map[string]*clientListGroup{}
+	for s := range strings.SplitSeq(input, "\n") {
+		info, ok := parseClientListString(s)
+		if !ok {
+			log.Debugf("parseClientListString( %s ) - couldn't parse input", s)
+			continue
+		}
+
+		labelValues := make([]string, len(e.clientListDimensions))
+		for i, d := range e.clientListDimensions {
+			labelValues[i] = d.value(info)
+		}
+		key := strings.Join(labelValues, "\x00")
+		g := groups[key]
+		if g == nil {
+			g = &clientListGroup{labelValues: labelValues}
+			groups[key] = g
+		}
+		g.add(info, now)
+	}
+
+	for _, g := range groups {
+		e.registerConstMetricGauge(ch, "client_list_group_clients", g.clients, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_query_buffer_length_bytes_sum", g.qbufSum, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_query_buffer_length_bytes_max", g.qbufMax, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_output_buffer_memory_bytes_sum", g.omemSum, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_output_buffer_memory_bytes_max", g.omemMax, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_total_memory_bytes_sum", g.totMemSum, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_total_memory_bytes_max", g.totMemMax, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_idle_seconds_sum", g.idleSum, g.labelValues...)
+		e.registerConstMetricGauge(ch, "client_list_group_idle_seconds_max", g.idleMax, g.labelValues...)
+	}
+}
@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestParseClientListDimensions(t *testing.T) {
+	for _, tst := range []struct {
+		arg    string
+		labels string
+		ok     bool
+	}{
+		{arg: "", labels: "", ok: true},
+		{arg: "name, user,lib-name,lib-ver", labels: "name,user,lib_name,lib_ver", ok: true},
+		{arg: "flags,db,ip/24", labels: "flags,db,ip", ok: true},
+		{arg: "addr", ok: false},
+		{arg: "ip/abc", ok: false},
+		{arg: "ip/129", ok: false},
+		{arg: "ip,ip/24", ok: false},
+	} {
+		dimensions, err := parseClientListDimensions(tst.arg)
+		if (err == nil) != tst.ok {
+			t.Errorf("arg %q: expected ok: %t, got err: %v", tst.arg, tst.ok, err)
+			continue
+		}
+		var labels []string
+		for _, d := range dimensions {
+			labels = append(labels, d.label)
+		}
+		if got := strings.Join(labels, ","); got != tst.labels {
+			t.Errorf("arg %q: expected labels %q, got %q", tst.arg, tst.labels, got)
+		}
+	}
+}
+
+func TestClientNetwork(t *testing.T) {
+	for _, tst := range []struct {
+		host string
+		bits int
+		want string
+	}{
+		{host: "10.0.1.17", bits: 24, want: "10.0.1.0/24"},
+		{host: "10.0.1.17", bits: 64, want: "10.0.1.17/32"},
+		{host: "fd40:1481:21:dbe0:7021:300:a03:1a06", bits: 64, want: "fd40:1481:21:dbe0::/64"},
+		{host: "/tmp/redis.sock", bits: 24, want: "/tmp/redis.sock"},
+	} {
+		if got := clientNetwork(tst.host, tst.bits); got != tst.want {
+			t.Errorf("clientNetwork(%s, %d): expected %s, got %s", tst.host, tst.bits, tst.want, got)
+		}
+	}
+}
+
+func TestClientListGroupMetrics(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", ClientListAggregateBy: "lib-name,ip/24"})
+	if err != nil {
+		t.Fatalf("NewRedisExporter() err: %s", err)
+	}
+
+	clientList := strings.Join([]string{
+		"id=11 addr=10.0.1.17:63508 fd=8 name=worker age=600 idle=10 flags=N db=0 sub=0 psub=0 multi=-1 qbuf=10 qbuf-free=0 obl=0 oll=0 omem=100 tot-mem=2000 events=r cmd=get user=default lib-name=redis-py lib-ver=5.0.1",
+		"id=12 addr=10.0.1.18:63509 fd=9 name=worker age=600 idle=30 flags=N db=0 sub=0 psub=0 multi=-1 qbuf=30 qbuf-free=0 obl=0 oll=0 omem=0 tot-mem=4000 events=r cmd=get user=default lib-name=redis-py lib-ver=5.0.1",
+		"id=13 addr=10.0.2.5:63510 fd=10 name= age=5 idle=0 flags=N db=0 sub=0 psub=0 multi=-1 qbuf=0 qbuf-free=0 obl=0 oll=0 omem=0 tot-mem=1000 events=r cmd=client user=default lib-name=go-redis lib-ver=9.5.1",
+	}, "\n")
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.parseClientListGroupMetrics(clientList, chM)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		if strings.Contains(desc, `help: "`+strings.TrimPrefix(name, "test_")+` metric"`) {
+			t.Errorf("no description for %s", name)
+		}
+		for _, l := range d.GetLabel() {
+			name += "/" + l.GetValue()
+		}
+		got[name] = d.GetGauge().GetValue()
+	}
+
+	for name, want := range map[string]float64{
+		"test_client_list_group_clients/10.0.1.0/24/redis-py":                        2,
+		"test_client_list_group_clients/10.0.2.0/24/go-redis":                        1,
+		"test_client_list_group_query_buffer_length_bytes_sum/10.0.1.0/24/redis-py":  40,
+		"test_client_list_group_query_buffer_length_bytes_max/10.0.1.0/24/redis-py":  30,
+		"test_client_list_group_output_buffer_memory_bytes_max/10.0.1.0/24/redis-py": 100,
+		"test_client_list_group_total_memory_bytes_sum/10.0.1.0/24/redis-py":         6000,
+		"test_client_list_group_total_memory_bytes_max/10.0.2.0/24/go-redis":         1000,
+		"test_client_list_group_output_buffer_memory_bytes_sum/10.0.2.0/24/go-redis": 0,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+
+	// idle is derived from the current time, allow for a slow test run
+	if v := got["test_client_list_group_idle_seconds_max/10.0.1.0/24/redis-py"]; v < 30 || v > 32 {
+		t.Errorf("expected max idle of 30s, got %f", v)
+	}
+	if v := got["test_client_list_group_idle_seconds_sum/10.0.1.0/24/redis-py"]; v < 40 || v > 44 {
+		t.Errorf("expected an idle sum of 40s, got %f", v)
+	}
+}
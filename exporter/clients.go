@@ -13,6 +13,28 @@ import (
 
 var reClientListId = regexp.MustCompile(`^id=\d+ addr=\S+`)
 
+var clientLifetimeBuckets = []float64{1, 10, 60, 300, 900, 3600, 14400, 86400, 604800}
+
+type clientChurnKey struct {
+	libName string
+	user    string
+	name    string
+}
+
+type trackedClient struct {
+	key clientChurnKey
+	age int64
+}
+
+// clientChurn holds the clients seen in the last CLIENT LIST and the counters of the connections
+// opened and closed since, connections opened and closed between two scrapes aren't seen at all.
+type clientChurn struct {
+	clients   map[string]trackedClient
+	opened    map[clientChurnKey]float64
+	closed    map[clientChurnKey]float64
+	lifetimes map[clientChurnKey]*histogramAccumulator
+}
+
 type ClientInfo struct {
 	Id,
 	Name,
@@ -21,7 +43,9 @@ type ClientInfo struct {
 	Db,
 	Host,
 	Port,
-	Resp string
+	Resp,
+	LibName,
+	LibVer string
 	CreatedAt,
 	IdleSince,
 	Sub,
@@ -111,6 +135,10 @@ func parseClientListString(clientInfo string) (*ClientInfo, bool) {
 			connectedClient.Port = hostPortString[len(hostPortString)-1]
 		case "resp":
 			connectedClient.Resp = vPart[1]
+		case "lib-name":
+			connectedClient.LibName = vPart[1]
+		case "lib-ver":
+			connectedClient.LibVer = vPart[1]
 		}
 	}
 
@@ -131,7 +159,80 @@ func (e *Exporter) extractConnectedClientMetrics(ch chan<- prometheus.Metric, c
 		log.Errorf("CLIENT LIST err: %s", err)
 		return
 	}
-	e.parseConnectedClientMetrics(reply, ch)
+	if e.options.ExportClientList {
+		e.parseConnectedClientMetrics(reply, ch)
+	}
+	if len(e.clientListDimensions) > 0 {
+		e.parseClientListGroupMetrics(reply, ch)
+	}
+	if e.options.ExportClientChurn {
+		// the connection of the exporter is a new one with every scrape
+		ownId, err := redis.String(doRedisCmd(c, "CLIENT", "ID"))
+		if err != nil {
+			log.Errorf("CLIENT ID err: %s", err)
+			return
+		}
+		e.trackClientChurn(reply, ownId)
+		e.registerClientChurnMetrics(ch)
+	}
+}
+
+func (e *Exporter) trackClientChurn(input string, ownId string) {
+	clients := map[string]trackedClient{}
+	now := time.Now().Unix()
+	for s := range strings.SplitSeq(input, "\n") {
+		info, ok := parseClientListString(s)
+		if !ok || info.Id == ownId {
+			continue
+		}
+		clients[info.Id] = trackedClient{
+			key: clientChurnKey{libName: info.LibName, user: info.User, name: info.Name},
+			age: now - info.CreatedAt,
+		}
+	}
+
+	if e.clientChurn == nil {
+		// the first CLIENT LIST is the baseline, its clients weren't opened since the last scrape
+		e.clientChurn = &clientChurn{
+			clients:   clients,
+			opened:    map[clientChurnKey]float64{},
+			closed:    map[clientChurnKey]float64{},
+			lifetimes: map[clientChurnKey]*histogramAccumulator{},
+		}
+		return
+	}
+
+	for id, client := range clients {
+		if _, ok := e.clientChurn.clients[id]; !ok {
+			e.clientChurn.opened[client.key]++
+		}
+	}
+	for id, client := range e.clientChurn.clients {
+		if _, ok := clients[id]; ok {
+			continue
+		}
+		e.clientChurn.closed[client.key]++
+		// the age when the client was seen last, a lower bound of its lifetime
+		h := e.clientChurn.lifetimes[client.key]
+		if h == nil {
+			h = newHistogramAccumulator(clientLifetimeBuckets)
+			e.clientChurn.lifetimes[client.key] = h
+		}
+		h.observe(float64(client.age))
+	}
+	e.clientChurn.clients = clients
+}
+
+func (e *Exporter) registerClientChurnMetrics(ch chan<- prometheus.Metric) {
+	for k, cnt := range e.clientChurn.opened {
+		e.registerConstMetric(ch, "client_connections_opened_total", cnt, prometheus.CounterValue, k.libName, k.user, k.name)
+	}
+	for k, cnt := range e.clientChurn.closed {
+		e.registerConstMetric(ch, "client_connections_closed_total", cnt, prometheus.CounterValue, k.libName, k.user, k.name)
+	}
+	for k, h := range e.clientChurn.lifetimes {
+		e.registerConstHistogram(ch, "client_connection_lifetime_seconds", h.count, h.sum, h.buckets(), k.libName, k.user, k.name)
+	}
 }
 
 func (e *Exporter) parseConnectedClientMetrics(input string, ch chan<- prometheus.Metric) {
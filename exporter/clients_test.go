@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestDurationFieldToTimestamp(t *testing.T) {
@@ -74,7 +75,7 @@ func TestParseClientListString(t *testing.T) {
 		}, {
 			in:           "id=40253233 addr=fd40:1481:21:dbe0:7021:300:a03:1a06:44426 fd=19 name= age=782 idle=0 flags=N db=0 sub=896 psub=18 ssub=17 watch=3 multi=-1 qbuf=26 qbuf-free=32742 argv-mem=10 obl=0 oll=555 omem=0 tot-mem=61466 ow=0 owmem=0 events=r cmd=client user=default lib-name=redis-py lib-ver=5.0.1 numops=9",
 			expectedOk:   true,
-			expectedInfo: ClientInfo{Id: "40253233", CreatedAt: convertDurationToTimestampInt64("782"), IdleSince: convertDurationToTimestampInt64("0"), Flags: "N", Db: "0", Sub: 896, Psub: 18, Ssub: 17, Watch: 3, Qbuf: 26, QbufFree: 32742, Oll: 555, OMem: 0, TotMem: 61466, Host: "fd40:1481:21:dbe0:7021:300:a03:1a06", Port: "44426", User: "default"},
+			expectedInfo: ClientInfo{Id: "40253233", CreatedAt: convertDurationToTimestampInt64("782"), IdleSince: convertDurationToTimestampInt64("0"), Flags: "N", Db: "0", Sub: 896, Psub: 18, Ssub: 17, Watch: 3, Qbuf: 26, QbufFree: 32742, Oll: 555, OMem: 0, TotMem: 61466, Host: "fd40:1481:21:dbe0:7021:300:a03:1a06", Port: "44426", User: "default", LibName: "redis-py", LibVer: "5.0.1"},
 		}, {
 			in:         "id=14 addr=127.0.0.1:64958 fd=9 name=foo age=ABCDE idle=0 flags=N db=1 sub=0 psub=0 multi=-1 qbuf=26 qbuf-free=32742 obl=0 oll=0 omem=0 tot-mem=0 events=r cmd=client",
 			expectedOk: false,
@@ -270,3 +271,55 @@ func TestExportClientListInclPort(t *testing.T) {
 		}
 	}
 }
+
+func TestClientChurn(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", ExportClientChurn: true})
+
+	clientList := func(clients ...string) string {
+		return strings.Join(clients, "\n")
+	}
+	const (
+		worker1   = "id=11 addr=127.0.0.1:63508 fd=8 name=worker age=120 idle=0 flags=N db=0 user=default lib-name=redis-py"
+		worker2   = "id=12 addr=127.0.0.1:63509 fd=9 name=worker age=30 idle=0 flags=N db=0 user=default lib-name=redis-py"
+		worker3   = "id=13 addr=127.0.0.1:63510 fd=10 name=worker age=0 idle=0 flags=N db=0 user=default lib-name=redis-py"
+		exporter1 = "id=20 addr=127.0.0.1:63600 fd=11 name=redis_exporter age=0 idle=0 flags=N db=0 user=default"
+		exporter2 = "id=21 addr=127.0.0.1:63601 fd=11 name=redis_exporter age=0 idle=0 flags=N db=0 user=default"
+	)
+
+	// the first CLIENT LIST is the baseline
+	e.trackClientChurn(clientList(worker1, worker2, exporter1), "20")
+	// the connection of the exporter is a different one with every scrape
+	e.trackClientChurn(clientList(worker1, worker3, exporter2), "21")
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.registerClientChurnMetrics(chM)
+		close(chM)
+	}()
+
+	got := map[string]*dto.Metric{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		for _, l := range d.GetLabel() {
+			name += "/" + l.GetValue()
+		}
+		got[name] = d
+	}
+
+	if len(got) != 3 {
+		t.Errorf("expected 3 metrics, got: %v", got)
+	}
+	if m := got["test_client_connections_opened_total/redis-py/worker/default"]; m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 opened connection, got: %v", m)
+	}
+	if m := got["test_client_connections_closed_total/redis-py/worker/default"]; m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 closed connection, got: %v", m)
+	}
+	h := got["test_client_connection_lifetime_seconds/redis-py/worker/default"].GetHistogram()
+	if h.GetSampleCount() != 1 || h.GetSampleSum() < 30 || h.GetSampleSum() > 32 {
+		t.Errorf("expected the lifetime of the closed connection to be 30s, got: %v", h)
+	}
+}
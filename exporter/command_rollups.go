@@ -0,0 +1,178 @@
+package exporter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// commandMetadata is what COMMAND INFO and COMMAND DOCS return about a command (or subcommand, e.g. "config|get")
+type commandMetadata struct {
+	flags         []string
+	aclCategories []string
+	group         string
+}
+
+type commandRollup struct {
+	calls float64
+	usec  float64
+}
+
+func (e *Exporter) extractCommandRollupMetrics(ch chan<- prometheus.Metric, c redis.Conn, infoAll string) {
+	// the command table only changes with the version (or loaded modules, which need a restart as well)
+	if version := getInfoFieldValue(infoAll, "redis_version") + "/" + getInfoFieldValue(infoAll, "run_id"); e.commandMetadata == nil || version != e.commandMetadataVersion {
+		metadata, err := getCommandMetadata(c)
+		if err != nil {
+			log.Errorf("extractCommandRollupMetrics() err: %s", err)
+			return
+		}
+		log.Debugf("refreshed command metadata for %s, %d commands", version, len(metadata))
+		e.commandMetadata, e.commandMetadataVersion = metadata, version
+	}
+
+	byCategory := map[string]*commandRollup{}
+	byGroup := map[string]*commandRollup{}
+	byFlag := map[string]*commandRollup{}
+	add := func(rollups map[string]*commandRollup, key string, calls float64, usec float64) {
+		r := rollups[key]
+		if r == nil {
+			r = &commandRollup{}
+			rollups[key] = r
+		}
+		r.calls += calls
+		r.usec += usec
+	}
+
+	fieldClass := ""
+	for line := range strings.SplitSeq(infoAll, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			fieldClass = line[2:]
+			continue
+		}
+		if fieldClass != "Commandstats" {
+			continue
+		}
+		fieldKey, fieldValue, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		cmd, calls, _, _, usec, _, err := parseMetricsCommandStats(fieldKey, fieldValue)
+		if err != nil {
+			continue
+		}
+
+		md, ok := e.commandMetadata[cmd]
+		if !ok {
+			add(byGroup, "unknown", calls, usec)
+			continue
+		}
+		for _, category := range md.aclCategories {
+			add(byCategory, category, calls, usec)
+		}
+		for _, flag := range md.flags {
+			add(byFlag, flag, calls, usec)
+		}
+		if md.group != "" {
+			add(byGroup, md.group, calls, usec)
+		}
+	}
+
+	for category, r := range byCategory {
+		e.registerConstMetric(ch, "commands_by_acl_category_total", r.calls, prometheus.CounterValue, category)
+		e.registerConstMetric(ch, "commands_by_acl_category_duration_seconds_total", r.usec/1e6, prometheus.CounterValue, category)
+	}
+	for group, r := range byGroup {
+		e.registerConstMetric(ch, "commands_by_group_total", r.calls, prometheus.CounterValue, group)
+		e.registerConstMetric(ch, "commands_by_group_duration_seconds_total", r.usec/1e6, prometheus.CounterValue, group)
+	}
+	for flag, r := range byFlag {
+		e.registerConstMetric(ch, "commands_by_flag_total", r.calls, prometheus.CounterValue, flag)
+		e.registerConstMetric(ch, "commands_by_flag_duration_seconds_total", r.usec/1e6, prometheus.CounterValue, flag)
+	}
+}
+
+// getCommandMetadata builds the metadata of all commands and subcommands from COMMAND INFO and, on Redis 7+, COMMAND DOCS
+func getCommandMetadata(c redis.Conn) (map[string]commandMetadata, error) {
+	info, err := redis.Values(doRedisCmd(c, "COMMAND", "INFO"))
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]commandMetadata{}
+	parseCommandInfo(info, metadata)
+
+	docs, err := redis.Values(doRedisCmd(c, "COMMAND", "DOCS"))
+	if err != nil {
+		// COMMAND DOCS was added in Redis 7.0
+		log.Debugf("COMMAND DOCS err: %s", err)
+		return metadata, nil
+	}
+	parseCommandDocs(docs, "", metadata)
+	return metadata, nil
+}
+
+/*
+parseCommandInfo parses the (nested) reply of COMMAND INFO, every command is
+[name, arity, flags, first key, last key, step, acl categories, tips, key specs, subcommands]
+the ACL categories are only returned by Redis 6.0+, the subcommands by Redis 7.0+
+*/
+func parseCommandInfo(commands []any, metadata map[string]commandMetadata) {
+	for _, cmd := range commands {
+		details, err := redis.Values(cmd, nil)
+		if err != nil || len(details) < 3 {
+			continue
+		}
+		name, _ := redis.String(details[0], nil)
+		flags, _ := redis.Strings(details[2], nil)
+
+		var categories []string
+		if len(details) > 6 {
+			categories, _ = redis.Strings(details[6], nil)
+			for i, category := range categories {
+				categories[i] = strings.TrimPrefix(category, "@")
+			}
+		}
+		sort.Strings(flags)
+		sort.Strings(categories)
+		metadata[strings.ToLower(name)] = commandMetadata{flags: flags, aclCategories: categories}
+
+		if len(details) > 9 {
+			if subcommands, err := redis.Values(details[9], nil); err == nil {
+				parseCommandInfo(subcommands, metadata)
+			}
+		}
+	}
+}
+
+// parseCommandDocs adds the group of the commands from the RESP2 reply of COMMAND DOCS: [name, [field, value, ...], ...]
+// subcommands are in the "subcommands" field and inherit the group of their parent if they don't have one
+func parseCommandDocs(docs []any, parentGroup string, metadata map[string]commandMetadata) {
+	for i := 0; i+1 < len(docs); i += 2 {
+		name, _ := redis.String(docs[i], nil)
+		name = strings.ToLower(name)
+		fields, err := redis.Values(docs[i+1], nil)
+		if err != nil {
+			continue
+		}
+
+		group := parentGroup
+		var subcommands []any
+		for j := 0; j+1 < len(fields); j += 2 {
+			switch field, _ := redis.String(fields[j], nil); field {
+			case "group":
+				group, _ = redis.String(fields[j+1], nil)
+			case "subcommands":
+				subcommands, _ = redis.Values(fields[j+1], nil)
+			}
+		}
+
+		if md, ok := metadata[name]; ok {
+			md.group = group
+			metadata[name] = md
+		}
+		parseCommandDocs(subcommands, group, metadata)
+	}
+}
@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// testCommandInfo is the RESP2 reply of COMMAND INFO of Redis 7 for GET, SET, BLPOP and CONFIG (with CONFIG GET)
+var testCommandInfo = []any{
+	[]any{[]byte("get"), int64(2), []any{"readonly", "fast"}, int64(1), int64(1), int64(1), []any{"@read", "@string", "@fast"}, []any{}, []any{}, []any{}},
+	[]any{[]byte("set"), int64(-3), []any{"write", "denyoom"}, int64(1), int64(1), int64(1), []any{"@write", "@string", "@slow"}, []any{}, []any{}, []any{}},
+	[]any{[]byte("blpop"), int64(-3), []any{"write", "blocking"}, int64(1), int64(-2), int64(1), []any{"@write", "@list", "@slow", "@blocking"}, []any{}, []any{}, []any{}},
+	[]any{[]byte("config"), int64(-2), []any{}, int64(0), int64(0), int64(0), []any{"@slow"}, []any{}, []any{}, []any{
+		[]any{[]byte("config|get"), int64(-3), []any{"admin", "noscript", "loading", "stale"}, int64(0), int64(0), int64(0), []any{"@admin", "@slow", "@dangerous"}, []any{}, []any{}, []any{}},
+	}},
+}
+
+var testCommandDocs = []any{
+	[]byte("get"), []any{[]byte("summary"), []byte("Returns the string value of a key."), []byte("group"), []byte("string")},
+	[]byte("set"), []any{[]byte("summary"), []byte("Sets the string value of a key."), []byte("group"), []byte("string")},
+	[]byte("blpop"), []any{[]byte("summary"), []byte("Removes and returns the first element in a list."), []byte("group"), []byte("list")},
+	[]byte("config"), []any{[]byte("group"), []byte("server"), []byte("subcommands"), []any{
+		[]byte("config|get"), []any{[]byte("summary"), []byte("Returns the effective values of configuration parameters.")},
+	}},
+}
+
+const testCommandRollupsInfo = `# Server
+redis_version:7.2.4
+run_id:ae3e5ea9b2b2e0f6a2f4b5c3b4d1e5f0a9c8b7d6
+
+# Commandstats
+cmdstat_get:calls=10,usec=20,usec_per_call=2.00,rejected_calls=0,failed_calls=0
+cmdstat_set:calls=5,usec=30,usec_per_call=6.00,rejected_calls=0,failed_calls=0
+cmdstat_blpop:calls=2,usec=1000000,usec_per_call=500000.00,rejected_calls=0,failed_calls=0
+cmdstat_config|get:calls=3,usec=60,usec_per_call=20.00,rejected_calls=0,failed_calls=0
+cmdstat_graph.query:calls=4,usec=400,usec_per_call=100.00,rejected_calls=0,failed_calls=0
+`
+
+func TestParseCommandMetadata(t *testing.T) {
+	metadata := map[string]commandMetadata{}
+	parseCommandInfo(testCommandInfo, metadata)
+	parseCommandDocs(testCommandDocs, "", metadata)
+
+	if len(metadata) != 5 {
+		t.Fatalf("expected 5 commands, got: %#v", metadata)
+	}
+	if md := metadata["blpop"]; md.group != "list" || strings.Join(md.flags, ",") != "blocking,write" || strings.Join(md.aclCategories, ",") != "blocking,list,slow,write" {
+		t.Errorf("unexpected metadata of blpop: %#v", md)
+	}
+	if md := metadata["config|get"]; md.group != "server" || strings.Join(md.aclCategories, ",") != "admin,dangerous,slow" {
+		t.Errorf("unexpected metadata of config|get: %#v", md)
+	}
+}
+
+func TestCommandRollupMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclCommandRollups: true})
+
+	commandCalls := 0
+	docsErr := error(nil)
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		commandCalls++
+		if args[0] == "DOCS" {
+			return testCommandDocs, docsErr
+		}
+		return testCommandInfo, nil
+	}}
+
+	collect := func(info string) map[string]float64 {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractCommandRollupMetrics(chM, c, info)
+			close(chM)
+		}()
+
+		got := map[string]float64{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			desc := m.Desc().String()
+			name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+			for _, l := range d.GetLabel() {
+				name += "/" + l.GetValue()
+			}
+			got[name] = d.GetCounter().GetValue()
+		}
+		return got
+	}
+
+	got := collect(testCommandRollupsInfo)
+	for name, want := range map[string]float64{
+		"test_commands_by_acl_category_total/write":                  7,
+		"test_commands_by_acl_category_total/slow":                   10,
+		"test_commands_by_acl_category_total/string":                 15,
+		"test_commands_by_acl_category_duration_seconds_total/write": 1.00003,
+		"test_commands_by_group_total/string":                        15,
+		"test_commands_by_group_total/server":                        3,
+		"test_commands_by_group_total/unknown":                       4,
+		"test_commands_by_group_duration_seconds_total/list":         1,
+		"test_commands_by_flag_total/blocking":                       2,
+		"test_commands_by_flag_total/write":                          7,
+		"test_commands_by_flag_total/readonly":                       10,
+		"test_commands_by_flag_duration_seconds_total/admin":         0.00006,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+	if commandCalls != 2 {
+		t.Errorf("expected COMMAND INFO and COMMAND DOCS to be called once, got %d calls", commandCalls)
+	}
+
+	// the metadata is cached as long as the version doesn't change
+	collect(testCommandRollupsInfo)
+	if commandCalls != 2 {
+		t.Errorf("expected the command metadata to be cached, got %d calls", commandCalls)
+	}
+
+	// no groups before Redis 7
+	docsErr = errors.New("ERR unknown subcommand 'DOCS'")
+	got = collect(strings.Replace(testCommandRollupsInfo, "redis_version:7.2.4", "redis_version:6.2.14", 1))
+	if commandCalls != 4 {
+		t.Errorf("expected the command metadata to be refreshed after the version changed, got %d calls", commandCalls)
+	}
+	if _, ok := got["test_commands_by_group_total/string"]; ok {
+		t.Errorf("didn't expect command groups without COMMAND DOCS")
+	}
+	if v := got["test_commands_by_acl_category_total/read"]; v != 10 {
+		t.Errorf("metric test_commands_by_acl_category_total/read: want 10, got %f", v)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package exporter
+
+import "syscall"
+
+// getDiskUsage returns the bytes available to unprivileged users and the size of the filesystem of the path
+func getDiskUsage(path string) (free float64, total float64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return float64(uint64(st.Bavail) * uint64(st.Bsize)), float64(uint64(st.Blocks) * uint64(st.Bsize)), nil
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package exporter
+
+import "errors"
+
+func getDiskUsage(path string) (free float64, total float64, err error) {
+	return 0, 0, errors.New("disk usage is not supported on this platform")
+}
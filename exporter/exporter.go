@@ -15,7 +15,6 @@ import (
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -50,6 +49,55 @@ type Exporter struct {
 	// FalkorDB graph memory cache
 	graphMemoryCache     []graphMemoryResult
 	graphMemoryCacheTime time.Time
+
+	// number of entries added per stream during the last scrape
+	streamProductionSamples map[dbKeyPair]streamProductionSample
+
+	// latency spikes ingested from LATENCY HISTORY, keyed by event name
+	latencyHistory map[string]*latencyHistoryEvent
+
+	// COMMAND INFO / COMMAND DOCS metadata for the command stats rollups, refreshed when the version changes
+	commandMetadata        map[string]commandMetadata
+	commandMetadataVersion string
+
+	// counters of the ACL LOG entries, only the increase of the entries since the last scrape is added
+	aclLog *aclLogState
+
+	// state of the big keys / hot keys scan, resumed with every scrape
+	bigKeys *bigKeysScan
+
+	// incremental background scan for the key group metrics
+	keyGroupsScan     *keyGroupsBackgroundScan
+	keyGroupsScanOnce sync.Once
+
+	// counters of the keyspace notifications received by the background subscriber
+	keyspaceEventsOnce    sync.Once
+	keyspaceEventsMtx     sync.Mutex
+	keyspaceEventCounters map[keyspaceEventKey]float64
+	// the key groups that got their own counters per db, limited by MaxDistinctKeyGroups
+	keyspaceEventGroups map[string]map[string]bool
+	// the check-key-groups config the keys of the keyspace events are classified by
+	keyspaceEventKeyGroups keyGroupsConfig
+
+	// the dimensions of client-list-aggregate-by
+	clientListDimensions []clientListDimension
+
+	// samples of master_repl_offset of the previous scrapes to estimate the replication delay of the replicas
+	replOffsetSamples []replOffsetSample
+	replOffsetReplId  string
+
+	// the clients of the last CLIENT LIST and the counters of opened and closed connections
+	clientChurn *clientChurn
+
+	// Sentinel failover history, keyed by master name
+	sentinelMasters       map[string]*sentinelMasterHistory
+	sentinelEventsOnce    sync.Once
+	sentinelEventsMtx     sync.Mutex
+	sentinelEventCounters map[sentinelEventKey]float64
+
+	// closed by Stop() to terminate background goroutines
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 type Options struct {
@@ -63,10 +111,23 @@ type Options struct {
 	CheckStreams                    string
 	CheckSingleStreams              string
 	StreamsExcludeConsumerMetrics   bool
+	StreamsExcludeProductionRate    bool
+	StreamsInclPendingSummary       bool
+	StreamsDeliveryThreshold        int64
 	CheckKeysBatchSize              int64
 	CheckKeyGroups                  string
+	CheckKeyDistributions           string
+	CheckKeyDistributionTypes       string
+	CheckBigKeysBudget              int64
+	CheckBigKeysTopN                int64
 	MaxDistinctKeyGroups            int64
+	KeyGroupsScanInterval           time.Duration
+	KeyGroupsScanKeysPerTick        int64
+	KeyGroupsAutoDelimiter          string
+	KeyGroupsAutoDepth              int64
+	KeyspaceEvents                  string
 	CountKeys                       string
+	CountKeysInclTTL                bool
 	LuaScript                       map[string][]byte
 	LuaScriptReadOnly               bool
 	ClientCertFile                  string
@@ -74,8 +135,18 @@ type Options struct {
 	CaCertFile                      string
 	InclConfigMetrics               bool
 	InclModulesMetrics              bool
+	InclMemoryStats                 bool
+	InclMallocStats                 bool
+	InclCommandRollups              bool
+	InclAclMetrics                  bool
+	ExcludeAclLogCounters           bool
+	InclLatencyHistory              bool
+	InclNativeLatencyHistograms     bool
+	InclSlowlogExemplars            bool
 	InclSearchIndexesMetrics        bool
 	InclSentinelPeerInfo            bool
+	SentinelSubscribeEvents         bool
+	ExcludeSentinelFailoverMetrics  bool
 	CheckSearchIndexes              string
 	DisableExportingKeyValues       bool
 	ExcludeLatencyHistogramMetrics  bool
@@ -88,9 +159,14 @@ type Options struct {
 	ClusterDiscoverHostnames        bool
 	ExportClientList                bool
 	ExportClientsInclPort           bool
+	ClientListAggregateBy           string
+	ExportClientChurn               bool
+	InclReplicationTopology         bool
 	InclAofFileSize                 bool
 	SlowlogHistoryEnabled           bool
 	OverrideAofFilePath             string
+	InclPersistenceFiles            bool
+	OverridePersistenceDir          string
 	ConnectionTimeouts              time.Duration
 	MetricsPath                     string
 	RedisMetricsOnly                bool
@@ -110,6 +186,17 @@ type Options struct {
 	FalkorDBGraphMemoryCacheTTL     time.Duration
 	AppendInstanceRoleLabel         bool
 	DisableScrapeEndpoint           bool
+	OtlpEndpoint                    string
+	OtlpProtocol                    string
+	OtlpPushInterval                time.Duration
+	RemoteWriteURL                  string
+	RemoteWritePushInterval         time.Duration
+	RemoteWriteQueueSize            int64
+	RemoteWriteExtraLabels          string
+	StatsdAddress                   string
+	GraphiteAddress                 string
+	SinkPushInterval                time.Duration
+	SinkMetricNameTemplate          string
 }
 
 func getInstanceRoleFromInfo(info string) string {
@@ -123,6 +210,16 @@ func getInstanceRoleFromInfo(info string) string {
 	return ""
 }
 
+// getInfoFieldValue returns the value of a field of the INFO output, e.g. redis_version
+func getInfoFieldValue(info string, field string) string {
+	for line := range strings.SplitSeq(info, "\n") {
+		if val, ok := strings.CutPrefix(strings.TrimSpace(line), field+":"); ok {
+			return val
+		}
+	}
+	return ""
+}
+
 // NewRedisExporter returns a new exporter of Redis metrics.
 func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 	log.Debugf("NewRedisExporter options: %#v", opts)
@@ -146,6 +243,13 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 
 		buildInfo: opts.BuildInfo,
 
+		sentinelMasters:       map[string]*sentinelMasterHistory{},
+		keyspaceEventCounters: map[keyspaceEventKey]float64{},
+		keyspaceEventGroups:   map[string]map[string]bool{},
+		latencyHistory:        map[string]*latencyHistoryEvent{},
+		sentinelEventCounters: map[sentinelEventKey]float64{},
+		stopCh:                make(chan struct{}),
+
 		totalScrapes: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: opts.Namespace,
 			Name:      "exporter_scrapes_total",
@@ -506,6 +610,28 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		log.Debugf("singleStreams: %#v", singleStreams)
 	}
 
+	if keyDistributions, err := parseKeyArg(opts.CheckKeyDistributions); err != nil {
+		return nil, fmt.Errorf("couldn't parse check-key-distributions: %s", err)
+	} else {
+		log.Debugf("keyDistributions: %#v", keyDistributions)
+	}
+
+	if _, err := parseKeyDistributionTypes(opts.CheckKeyDistributionTypes); err != nil {
+		return nil, fmt.Errorf("couldn't parse check-key-distribution-types: %s", err)
+	}
+
+	if dimensions, err := parseClientListDimensions(opts.ClientListAggregateBy); err != nil {
+		return nil, fmt.Errorf("couldn't parse client-list-aggregate-by: %s", err)
+	} else {
+		e.clientListDimensions = dimensions
+	}
+
+	if keyGroups, err := newKeyGroupsConfig(opts); err != nil {
+		return nil, err
+	} else {
+		e.keyspaceEventKeyGroups = keyGroups
+	}
+
 	if countKeys, err := parseKeyArg(opts.CountKeys); err != nil {
 		return nil, fmt.Errorf("couldn't parse count-keys: %s", err)
 	} else {
@@ -533,6 +659,8 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		"config_value":                                       {txt: `Config key and value as metric`, lbls: []string{"key"}},
 		"connected_slave_lag_seconds":                        {txt: "Lag of connected slave", lbls: []string{"slave_ip", "slave_port", "slave_state"}},
 		"connected_slave_offset_bytes":                       {txt: "Offset of connected slave", lbls: []string{"slave_ip", "slave_port", "slave_state"}},
+		"connected_slave_lag_bytes":                          {txt: `Lag of connected slave in bytes relative to master_repl_offset`, lbls: []string{"slave_ip", "slave_port", "slave_state"}},
+		"connected_slave_replication_delay_seconds":          {txt: `Estimated time in seconds since master_repl_offset was at the offset of the connected slave`, lbls: []string{"slave_ip", "slave_port", "slave_state"}},
 		"db_avg_ttl_seconds":                                 {txt: "Avg TTL in seconds", lbls: []string{"db"}},
 		"db_keys":                                            {txt: "Total number of keys by DB", lbls: []string{"db"}},
 		"db_keys_cached":                                     {txt: "Total number of cached keys by DB", lbls: []string{"db"}},
@@ -541,14 +669,98 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		"exporter_last_scrape_error":                         {txt: "The last scrape error status.", lbls: []string{"err"}},
 		"key_group_count":                                    {txt: `Count of keys in key group`, lbls: []string{"db", "key_group"}},
 		"key_group_memory_usage_bytes":                       {txt: `Total memory usage of key group in bytes`, lbls: []string{"db", "key_group"}},
+		"key_pattern_memory_usage_bytes":                     {txt: `Distribution of the memory usage in bytes of keys matching "pattern" by type`, lbls: []string{"db", "pattern", "type"}},
+		"key_pattern_size":                                   {txt: `Distribution of the length or size of keys matching "pattern" by type`, lbls: []string{"db", "pattern", "type"}},
+		"acl_log_entries_total":                              {txt: `Total number of denied commands, keys, channels and failed authentications logged by ACL LOG`, lbls: []string{"reason", "username", "client_name"}},
+		"acl_user_allowed_category":                          {txt: `Whether the ACL category is allowed for the user (+@category) according to ACL LIST`, lbls: []string{"user", "category"}},
+		"acl_user_enabled":                                   {txt: `Whether the user is enabled (1) or disabled (0) according to ACL LIST`, lbls: []string{"user"}},
+		"acl_user_nopass":                                    {txt: `Whether the user can authenticate with any password (1) or not (0) according to ACL LIST`, lbls: []string{"user"}},
+		"acl_user_rules":                                     {txt: `Number of rules of the user according to ACL LIST`, lbls: []string{"user"}},
+		"aof_base_file_sequence":                             {txt: `Sequence number of the base file in the AOF manifest, incremented by every AOF rewrite`},
+		"aof_incr_file_sequence":                             {txt: `Highest sequence number of the incr files in the AOF manifest`},
+		"aof_manifest_files":                                 {txt: `Number of files of the type (base, incr, history) in the AOF manifest`, lbls: []string{"type"}},
+		"aof_manifest_files_size_bytes":                      {txt: `Total size in bytes of the files of the type (base, incr, history) in the AOF manifest`, lbls: []string{"type"}},
+		"persistence_dir_free_bytes":                         {txt: `Free space in bytes of the filesystem of the persistence dir`},
+		"persistence_dir_total_bytes":                        {txt: `Size in bytes of the filesystem of the persistence dir`},
+		"rdb_file_modified_timestamp_seconds":                {txt: `Modification time of the RDB file as unix timestamp`},
+		"rdb_file_size_bytes":                                {txt: `Size of the RDB file in bytes`},
+		"big_key_memory_usage_bytes":                         {txt: `Memory usage in bytes of the biggest keys found by the big keys scan`, lbls: []string{"db", "key", "type"}},
+		"big_keys_scan_passes_total":                         {txt: `Total number of completed passes of the big keys scan over the keyspace`},
+		"big_keys_scanned_keys_total":                        {txt: `Total number of keys examined by the big keys scan`},
+		"client_connection_lifetime_seconds":                 {txt: `Distribution of the lifetime in seconds of the closed connections, as last seen in CLIENT LIST`, lbls: []string{"lib_name", "user", "name"}},
+		"client_connections_closed_total":                    {txt: `Total number of connections closed between two scrapes of CLIENT LIST`, lbls: []string{"lib_name", "user", "name"}},
+		"client_connections_opened_total":                    {txt: `Total number of connections opened between two scrapes of CLIENT LIST`, lbls: []string{"lib_name", "user", "name"}},
+		"commands_by_acl_category_total":                     {txt: `Total number of calls of the commands of the ACL category`, lbls: []string{"acl_category"}},
+		"commands_by_acl_category_duration_seconds_total":    {txt: `Total amount of time in seconds spent executing the commands of the ACL category`, lbls: []string{"acl_category"}},
+		"commands_by_flag_total":                             {txt: `Total number of calls of the commands with the flag (e.g. write, blocking)`, lbls: []string{"flag"}},
+		"commands_by_flag_duration_seconds_total":            {txt: `Total amount of time in seconds spent executing the commands with the flag`, lbls: []string{"flag"}},
+		"commands_by_group_total":                            {txt: `Total number of calls of the commands of the group (COMMAND DOCS)`, lbls: []string{"group"}},
+		"commands_by_group_duration_seconds_total":           {txt: `Total amount of time in seconds spent executing the commands of the group (COMMAND DOCS)`, lbls: []string{"group"}},
+		"hot_key_lfu_frequency":                              {txt: `LFU access frequency counter (OBJECT FREQ) of the most frequently accessed keys found by the big keys scan`, lbls: []string{"db", "key", "type"}},
+		"replication_chain_depth":                            {txt: `Number of hops from the instance to the master at the top of its replication chain, 0 for a master, 2 or more for a replica of a replica`},
+		"key_groups_scan_last_pass_duration_seconds":         {txt: `Duration of the last complete pass of the background key groups scan in seconds`},
+		"key_groups_scan_pass_progress_ratio":                {txt: `Keys scanned in the current pass of the background key groups scan relative to the number of keys at the start of the pass`},
+		"key_groups_scan_pass_scanned_keys":                  {txt: `Number of keys scanned in the current pass of the background key groups scan`},
+		"key_groups_scan_passes_total":                       {txt: `Total number of complete passes of the background key groups scan`},
+		"keyspace_events_total":                              {txt: `Total number of keyspace notifications received per event and key group`, lbls: []string{"db", "event", "key_group"}},
+		"malloc_stats_active_bytes":                          {txt: `Bytes in the active pages allocated by jemalloc as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_allocated_bytes":                       {txt: `Bytes allocated by the application via jemalloc as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_arenas":                                {txt: `Number of jemalloc arenas as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_dirty_pages":                           {txt: `Number of dirty pages of the merged jemalloc arenas that can be purged as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_mapped_bytes":                          {txt: `Bytes in the active extents mapped by jemalloc as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_metadata_bytes":                        {txt: `Bytes dedicated to the metadata of jemalloc as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_muzzy_pages":                           {txt: `Number of muzzy pages of the merged jemalloc arenas as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_resident_bytes":                        {txt: `Bytes in the physically resident data pages mapped by jemalloc as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_retained_bytes":                        {txt: `Bytes in the virtual memory mappings retained by jemalloc instead of being returned to the OS as reported by MEMORY MALLOC-STATS`},
+		"malloc_stats_bin_allocated_bytes":                   {txt: `Bytes allocated in the jemalloc bin of the size class`, lbls: []string{"size"}},
+		"malloc_stats_bin_fragmented_bytes":                  {txt: `Bytes of the unused regions in the slabs of the jemalloc bin of the size class`, lbls: []string{"size"}},
+		"malloc_stats_bin_regions":                           {txt: `Number of regions currently allocated in the jemalloc bin of the size class`, lbls: []string{"size"}},
+		"malloc_stats_bin_slabs":                             {txt: `Number of slabs currently used by the jemalloc bin of the size class`, lbls: []string{"size"}},
+		"malloc_stats_bin_utilization_ratio":                 {txt: `Ratio of allocated regions to the capacity of the slabs of the jemalloc bin of the size class`, lbls: []string{"size"}},
+		"memory_doctor_issue":                                {txt: `Whether MEMORY DOCTOR reports the issue (1) or not (0)`, lbls: []string{"issue"}},
+		"memory_stats_allocator_active_bytes":                {txt: `Bytes in the active pages of the allocator, including external fragmentation, as reported by MEMORY STATS`},
+		"memory_stats_allocator_allocated_bytes":             {txt: `Bytes allocated by the allocator, including internal fragmentation, as reported by MEMORY STATS`},
+		"memory_stats_allocator_fragmentation_bytes":         {txt: `Difference between allocator.active and allocator.allocated in bytes as reported by MEMORY STATS`},
+		"memory_stats_allocator_fragmentation_ratio":         {txt: `Ratio of allocator.active to allocator.allocated as reported by MEMORY STATS`},
+		"memory_stats_allocator_muzzy_bytes":                 {txt: `Bytes in the muzzy pages of the allocator as reported by MEMORY STATS`},
+		"memory_stats_allocator_resident_bytes":              {txt: `Bytes resident in the allocator, including pages that can be released to the OS, as reported by MEMORY STATS`},
+		"memory_stats_allocator_rss_bytes":                   {txt: `Difference between allocator.resident and allocator.active in bytes as reported by MEMORY STATS`},
+		"memory_stats_allocator_rss_ratio":                   {txt: `Ratio of allocator.resident to allocator.active as reported by MEMORY STATS`},
+		"memory_stats_aof_buffer_bytes":                      {txt: `Memory used by the AOF buffers in bytes as reported by MEMORY STATS`},
+		"memory_stats_clients_normal_bytes":                  {txt: `Memory used by the buffers of the normal clients in bytes as reported by MEMORY STATS`},
+		"memory_stats_clients_replicas_bytes":                {txt: `Memory used by the buffers of the replicas in bytes as reported by MEMORY STATS`},
+		"memory_stats_cluster_links_bytes":                   {txt: `Memory used by the links to the cluster bus peers in bytes as reported by MEMORY STATS`},
+		"memory_stats_dataset_bytes":                         {txt: `Size of the dataset (overhead.total subtracted from total.allocated) in bytes as reported by MEMORY STATS`},
+		"memory_stats_dataset_percentage":                    {txt: `Percentage of the dataset of the net memory usage as reported by MEMORY STATS`},
+		"memory_stats_db_dict_rehashing":                     {txt: `Number of dictionaries that are being rehashed as reported by MEMORY STATS`},
+		"memory_stats_fragmentation_bytes":                   {txt: `Difference between the RSS of the process and total.allocated in bytes as reported by MEMORY STATS`},
+		"memory_stats_fragmentation_ratio":                   {txt: `Ratio of the RSS of the process to total.allocated as reported by MEMORY STATS`},
+		"memory_stats_functions_caches_bytes":                {txt: `Memory used by the caches of the functions in bytes as reported by MEMORY STATS`},
+		"memory_stats_keys":                                  {txt: `Total number of keys of all dbs as reported by MEMORY STATS`},
+		"memory_stats_keys_bytes_per_key":                    {txt: `Net memory usage (total.allocated minus startup.allocated) per key in bytes as reported by MEMORY STATS`},
+		"memory_stats_lua_caches_bytes":                      {txt: `Memory used by the caches of the Lua scripts in bytes as reported by MEMORY STATS`},
+		"memory_stats_overhead_db_hashtable_lut_bytes":       {txt: `Memory used by the lookup tables of the hashtables of all dbs in bytes as reported by MEMORY STATS`},
+		"memory_stats_overhead_db_hashtable_rehashing_bytes": {txt: `Memory used by the hashtables that are being rehashed in bytes as reported by MEMORY STATS`},
+		"memory_stats_overhead_total_bytes":                  {txt: `Sum of all the overheads (startup, replication backlog, client buffers, AOF buffers, scripts and the dbs) in bytes as reported by MEMORY STATS`},
+		"memory_stats_peak_allocated_bytes":                  {txt: `Peak memory allocated by redis in bytes as reported by MEMORY STATS`},
+		"memory_stats_peak_percentage":                       {txt: `Percentage of total.allocated of peak.allocated as reported by MEMORY STATS`},
+		"memory_stats_replication_backlog_bytes":             {txt: `Memory used by the replication backlog in bytes as reported by MEMORY STATS`},
+		"memory_stats_rss_overhead_bytes":                    {txt: `Difference between the RSS of the process and allocator.resident in bytes as reported by MEMORY STATS`},
+		"memory_stats_rss_overhead_ratio":                    {txt: `Ratio of the RSS of the process to allocator.resident as reported by MEMORY STATS`},
+		"memory_stats_startup_allocated_bytes":               {txt: `Memory allocated by redis at startup in bytes as reported by MEMORY STATS`},
+		"memory_stats_total_allocated_bytes":                 {txt: `Total memory allocated by redis in bytes as reported by MEMORY STATS`},
+		"memory_stats_db_hashtable_overhead_bytes":           {txt: `Overhead of the main and expires hashtables of the db in bytes as reported by MEMORY STATS`, lbls: []string{"db", "hashtable"}},
 		"key_memory_usage_bytes":                             {txt: `The memory usage of "key" in bytes`, lbls: []string{"db", "key"}},
 		"key_size":                                           {txt: `The length or size of "key"`, lbls: []string{"db", "key"}},
 		"key_value":                                          {txt: `The value of "key"`, lbls: []string{"db", "key"}},
 		"key_value_as_string":                                {txt: `The value of "key" as a string`, lbls: []string{"db", "key", "val"}},
 		"keys_count":                                         {txt: `Count of keys`, lbls: []string{"db", "key"}},
+		"keys_ttl_seconds":                                   {txt: `Distribution of the remaining TTL in seconds of keys matching the count-keys pattern`, lbls: []string{"db", "key"}},
+		"keys_without_expiry_count":                          {txt: `Count of keys matching the count-keys pattern without an expiry`, lbls: []string{"db", "key"}},
 		"last_key_groups_scrape_duration_milliseconds":       {txt: `Duration of the last key group metrics scrape in milliseconds`},
 		"last_slow_execution_duration_seconds":               {txt: `The amount of time needed for last slow execution, in seconds`},
 		"latency_percentiles_usec":                           {txt: `A summary of latency percentile distribution per command`, lbls: []string{"cmd"}},
+		"latency_history_spike_duration_seconds":             {txt: `Distribution of the latency spikes per event in seconds, ingested from LATENCY HISTORY`, lbls: []string{"event_name"}},
 		"latency_spike_duration_seconds":                     {txt: `Length of the last latency spike in seconds`, lbls: []string{"event_name"}},
 		"latency_spike_last":                                 {txt: `When the latency spike last occurred`, lbls: []string{"event_name"}},
 		"master_last_io_seconds_ago":                         {txt: "Master last io seconds ago", lbls: []string{"master_host", "master_port"}},
@@ -592,6 +804,10 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		"sentinel_master_setting_failover_timeout":           {txt: "Show the current failover-timeout config for each master", lbls: []string{"master_name", "master_address"}},
 		"sentinel_master_setting_parallel_syncs":             {txt: "Show the current parallel-syncs config for each master", lbls: []string{"master_name", "master_address"}},
 		"sentinel_master_config_epoch":                       {txt: "The configuration epoch of the master (increments on each failover)", lbls: []string{"master_name", "master_address"}},
+		"sentinel_master_failovers_total":                    {txt: "Number of failovers (master address or config epoch changes) observed by the exporter between scrapes", lbls: []string{"master_name"}},
+		"sentinel_master_last_failover_timestamp_seconds":    {txt: "Timestamp of the last failover observed by the exporter", lbls: []string{"master_name"}},
+		"sentinel_master_previous_address_info":              {txt: "The address of the master before the last observed failover", lbls: []string{"master_name", "master_address", "previous_master_address"}},
+		"sentinel_events_total":                              {txt: "Number of events received on Sentinel's pub/sub channels per master", lbls: []string{"master_name", "event"}},
 		"sentinel_master_last_ok_ping_reply_seconds":         {txt: "Elapsed time in seconds since the last successful ping reply from the master", lbls: []string{"master_name", "master_address"}},
 		"sentinel_master_slaves":                             {txt: "The number of slaves of the master", lbls: []string{"master_name", "master_address"}},
 		"sentinel_master_status":                             {txt: "Master status on Sentinel", lbls: []string{"master_name", "master_address", "master_status"}},
@@ -608,14 +824,21 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		"slowlog_length":                                     {txt: `Total slowlog`},
 		"slowlog_history_last_ten":                           {txt: "last 10 slowlog commands", lbls: []string{"command_executed_timestamp", "command", "client"}},
 		"start_time_seconds":                                 {txt: "Start time of the Redis instance since unix epoch in seconds."},
+		"stream_entries_added_per_second":                    {txt: `Number of messages added to the stream per second, derived from entries-added between two scrapes`, lbls: []string{"db", "stream"}},
+		"stream_entries_added_total":                         {txt: `Total number of messages added to the stream (Redis 7.0+)`, lbls: []string{"db", "stream"}},
 		"stream_first_entry_id":                              {txt: `The epoch timestamp (ms) of the first message in the stream`, lbls: []string{"db", "stream"}},
 		"stream_group_consumer_idle_seconds":                 {txt: `Consumer idle time in seconds`, lbls: []string{"db", "stream", "group", "consumer"}},
 		"stream_group_consumer_messages_pending":             {txt: `Pending number of messages for this specific consumer`, lbls: []string{"db", "stream", "group", "consumer"}},
 		"stream_group_consumers":                             {txt: `Consumers count of stream group`, lbls: []string{"db", "stream", "group"}},
 		"stream_group_entries_read":                          {txt: `Total number of entries read from the stream group`, lbls: []string{"db", "stream", "group"}},
 		"stream_group_lag":                                   {txt: `The number of messages waiting to be delivered to the stream group's consumers`, lbls: []string{"db", "stream", "group"}},
+		"stream_group_lag_seconds":                           {txt: `Time difference in seconds between the newest message of the stream and the last message delivered to the stream group`, lbls: []string{"db", "stream", "group"}},
 		"stream_group_last_delivered_id":                     {txt: `The epoch timestamp (ms) of the last delivered message`, lbls: []string{"db", "stream", "group"}},
 		"stream_group_messages_pending":                      {txt: `Pending number of messages in that stream group`, lbls: []string{"db", "stream", "group"}},
+		"stream_group_pending_max_delivery_count":            {txt: `The highest delivery count of all pending messages of the stream group`, lbls: []string{"db", "stream", "group"}},
+		"stream_group_pending_max_idle_seconds":              {txt: `The longest time in seconds since a pending message of the stream group was last delivered`, lbls: []string{"db", "stream", "group"}},
+		"stream_group_pending_oldest_entry_age_seconds":      {txt: `Age in seconds of the oldest pending message of the stream group, derived from its ID`, lbls: []string{"db", "stream", "group"}},
+		"stream_group_pending_over_delivery_threshold":       {txt: `Number of pending messages of the stream group that were delivered more often than the configured threshold`, lbls: []string{"db", "stream", "group"}},
 		"stream_groups":                                      {txt: `Groups count of stream`, lbls: []string{"db", "stream"}},
 		"stream_last_entry_id":                               {txt: `The epoch timestamp (ms) of the last message in the stream`, lbls: []string{"db", "stream"}},
 		"stream_last_generated_id":                           {txt: `The epoch timestamp (ms) of the latest message on the stream`, lbls: []string{"db", "stream"}},
@@ -641,6 +864,19 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		e.metricDescriptions[k] = newMetricDescr(opts.Namespace, k, desc.txt, lbls)
 	}
 
+	if len(e.clientListDimensions) > 0 {
+		lbls := make([]string, 0, len(e.clientListDimensions)+1)
+		for _, d := range e.clientListDimensions {
+			lbls = append(lbls, d.label)
+		}
+		if e.options.AppendInstanceRoleLabel {
+			lbls = append(lbls, "instance_role")
+		}
+		for k, txt := range clientListGroupMetrics {
+			e.metricDescriptions[k] = newMetricDescr(opts.Namespace, k, txt, lbls)
+		}
+	}
+
 	if e.options.MetricsPath == "" {
 		e.options.MetricsPath = "/metrics"
 	}
@@ -649,9 +885,7 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 
 	if e.options.Registry != nil {
 		e.options.Registry.MustRegister(e)
-		e.mux.Handle(e.options.MetricsPath, promhttp.HandlerFor(
-			e.options.Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError},
-		))
+		e.mux.Handle(e.options.MetricsPath, metricsHandler(e.options.Registry, e.options.InclSlowlogExemplars))
 
 		if !e.options.RedisMetricsOnly {
 			buildInfoCollector := prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -664,11 +898,18 @@ func NewRedisExporter(uri string, opts Options) (*Exporter, error) {
 		}
 	}
 
+	if err := e.startPushes(); err != nil {
+		// stop the pushes that were already started, the caller doesn't get an exporter to Stop()
+		e.Stop()
+		return nil, err
+	}
+
 	e.mux.HandleFunc("/", e.indexHandler)
 	if !e.options.DisableScrapeEndpoint {
 		e.mux.HandleFunc("/scrape", e.scrapeHandler)
 	}
 	e.mux.HandleFunc("/discover-cluster-nodes", e.discoverClusterNodesHandler)
+	e.mux.HandleFunc("/replication-topology", e.replicationTopologyHandler)
 	e.mux.HandleFunc("/health", e.healthHandler)
 	e.mux.HandleFunc("/-/reload", e.reloadPwdFile)
 
@@ -867,11 +1108,19 @@ func (e *Exporter) scrapeRedisHost(ch chan<- prometheus.Metric) error {
 	log.Debugf("dbCount: %d", dbCount)
 
 	role := e.extractInfoMetrics(ch, infoAll, dbCount)
+	if e.options.InclReplicationTopology {
+		e.extractReplicationLagMetrics(ch, infoAll, time.Now())
+		e.extractReplicationChainDepthMetric(ch, infoAll)
+	}
 
 	if !e.options.ExcludeLatencyHistogramMetrics {
 		e.extractLatencyMetrics(ch, infoAll, c)
 	}
 
+	if e.options.InclLatencyHistory {
+		e.extractLatencyHistoryMetrics(ch, c)
+	}
+
 	var keyConn redis.Conn
 	if e.options.IsCluster {
 		//
@@ -904,8 +1153,12 @@ func (e *Exporter) scrapeRedisHost(ch chan<- prometheus.Metric) error {
 				log.Errorf("extractCheckKeyMetrics() err: %s", err)
 			}
 			e.extractCountKeysMetrics(ch, keyConn)
+			e.extractKeyDistributionMetrics(ch, keyConn)
 			e.extractStreamMetrics(ch, keyConn)
 		}
+		// the SCAN cursor of the big keys scan is resumed with the next scrape,
+		// so it has to run on the node's own connection even in cluster mode
+		e.extractBigKeysMetrics(ch, c, dbCount, maxmemoryPolicyIsLFU(infoAll))
 	} else {
 		log.Infof("skipping checkKeys metrics, role: %s  flag: %#v", role, e.options.SkipCheckKeysForRoleMaster)
 	}
@@ -920,13 +1173,15 @@ func (e *Exporter) scrapeRedisHost(ch chan<- prometheus.Metric) error {
 		e.extractKeyGroupMetrics(ch, keyConn, dbCount)
 	}
 
+	e.extractKeyspaceEventMetrics(ch, c)
+
 	if strings.Contains(infoAll, "# Sentinel") {
 		e.extractSentinelMetrics(ch, c)
 
 		e.extractSentinelConfig(ch, c)
 	}
 
-	if e.options.ExportClientList {
+	if e.options.ExportClientList || len(e.clientListDimensions) > 0 || e.options.ExportClientChurn {
 		e.extractConnectedClientMetrics(ch, c)
 	}
 
@@ -938,10 +1193,30 @@ func (e *Exporter) scrapeRedisHost(ch chan<- prometheus.Metric) error {
 		e.extractModulesMetrics(ch, c)
 	}
 
+	if e.options.InclMemoryStats {
+		e.extractMemoryStatsMetrics(ch, c)
+	}
+
+	if e.options.InclMallocStats {
+		e.extractMallocStatsMetrics(ch, c)
+	}
+
+	if e.options.InclCommandRollups {
+		e.extractCommandRollupMetrics(ch, c, infoAll)
+	}
+
+	if e.options.InclAclMetrics {
+		e.extractAclMetrics(ch, c)
+	}
+
 	if e.options.InclAofFileSize {
 		e.extractAofFileSizeMetrics(ch, c, e.options.ConfigCommandName, e.options.OverrideAofFilePath)
 	}
 
+	if e.options.InclPersistenceFiles {
+		e.extractPersistenceFilesMetrics(ch, c)
+	}
+
 	if e.options.IsFalkorDB {
 		e.extractFalkorDBMetrics(ch, c)
 	}
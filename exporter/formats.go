@@ -0,0 +1,218 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	formatPrometheus = "prometheus"
+	formatJSON       = "json"
+	formatInflux     = "influx"
+)
+
+/*
+metricsHandler serves the metrics of the registry in the format requested by the "format" query parameter,
+or by the Accept header if there's no such parameter. Prometheus (text, protobuf or OpenMetrics, as negotiated
+by promhttp) is the default, "json" and "influx" (InfluxDB line protocol) are supported as well.
+OpenMetrics is only negotiated with enableOpenMetrics, it's needed for the exemplars in the text format.
+*/
+func metricsHandler(registry *prometheus.Registry, enableOpenMetrics bool) http.Handler {
+	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError, EnableOpenMetrics: enableOpenMetrics})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" && strings.Contains(r.Header.Get("Accept"), "application/json") {
+			format = formatJSON
+		}
+
+		switch format {
+		case "", formatPrometheus:
+			promHandler.ServeHTTP(w, r)
+			return
+		case formatJSON, formatInflux:
+		default:
+			http.Error(w, fmt.Sprintf("Unsupported format %q, valid formats are prometheus, json and influx", format), http.StatusBadRequest)
+			return
+		}
+
+		families, err := registry.Gather()
+		if err != nil {
+			if len(families) == 0 {
+				http.Error(w, fmt.Sprintf("Failed to gather metrics: %s", err), http.StatusInternalServerError)
+				return
+			}
+			log.Errorf("Failed to gather some metrics: %s", err)
+		}
+
+		var buf bytes.Buffer
+		if format == formatJSON {
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(&buf).Encode(metricFamiliesToJSON(families))
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			writeInfluxLineProtocol(&buf, families, time.Now())
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to render metrics: %s", err), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// jsonFloat is a float64 that marshals NaN and +-Inf as strings because JSON can't represent them
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return json.Marshal(formatFloat(v))
+	}
+	return json.Marshal(v)
+}
+
+type jsonMetricFamily struct {
+	Name    string       `json:"name"`
+	Help    string       `json:"help"`
+	Type    string       `json:"type"`
+	Metrics []jsonMetric `json:"metrics"`
+}
+
+type jsonMetric struct {
+	Labels    map[string]string    `json:"labels"`
+	Value     *jsonFloat           `json:"value,omitempty"`
+	Count     *uint64              `json:"count,omitempty"`
+	Sum       *jsonFloat           `json:"sum,omitempty"`
+	Buckets   map[string]uint64    `json:"buckets,omitempty"`
+	Quantiles map[string]jsonFloat `json:"quantiles,omitempty"`
+}
+
+func metricFamiliesToJSON(families []*dto.MetricFamily) []jsonMetricFamily {
+	res := make([]jsonMetricFamily, 0, len(families))
+	for _, mf := range families {
+		family := jsonMetricFamily{
+			Name:    mf.GetName(),
+			Help:    mf.GetHelp(),
+			Type:    strings.ToLower(mf.GetType().String()),
+			Metrics: make([]jsonMetric, 0, len(mf.GetMetric())),
+		}
+		for _, m := range mf.GetMetric() {
+			metric := jsonMetric{Labels: map[string]string{}}
+			for _, l := range m.GetLabel() {
+				metric.Labels[l.GetName()] = l.GetValue()
+			}
+
+			value := func(v float64) *jsonFloat {
+				f := jsonFloat(v)
+				return &f
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				metric.Value = value(m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				metric.Value = value(m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				metric.Value = value(m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				count := m.GetSummary().GetSampleCount()
+				metric.Count = &count
+				metric.Sum = value(m.GetSummary().GetSampleSum())
+				metric.Quantiles = map[string]jsonFloat{}
+				for _, q := range m.GetSummary().GetQuantile() {
+					metric.Quantiles[formatFloat(q.GetQuantile())] = jsonFloat(q.GetValue())
+				}
+			case dto.MetricType_HISTOGRAM:
+				count := m.GetHistogram().GetSampleCount()
+				metric.Count = &count
+				metric.Sum = value(m.GetHistogram().GetSampleSum())
+				metric.Buckets = map[string]uint64{"+Inf": count}
+				for _, b := range m.GetHistogram().GetBucket() {
+					metric.Buckets[formatFloat(b.GetUpperBound())] = b.GetCumulativeCount()
+				}
+			}
+			family.Metrics = append(family.Metrics, metric)
+		}
+		res = append(res, family)
+	}
+	return res
+}
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	influxTagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+)
+
+/*
+writeInfluxLineProtocol renders the metric families as InfluxDB line protocol, one line per metric with the
+metric name as measurement and the labels as tags. Counters, gauges and untyped metrics have a single "value"
+field, histograms and summaries have "count", "sum" and one field per bucket or quantile, the same way
+Telegraf's prometheus input does it. Influx can't store NaN or Inf, such fields are left out.
+*/
+func writeInfluxLineProtocol(buf *bytes.Buffer, families []*dto.MetricFamily, now time.Time) {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			var fields []string
+			addField := func(name string, v float64) {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					return
+				}
+				fields = append(fields, influxTagEscaper.Replace(name)+"="+strconv.FormatFloat(v, 'g', -1, 64))
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				addField("value", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				addField("value", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				addField("value", m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				addField("count", float64(m.GetSummary().GetSampleCount()))
+				addField("sum", m.GetSummary().GetSampleSum())
+				for _, q := range m.GetSummary().GetQuantile() {
+					addField(formatFloat(q.GetQuantile()), q.GetValue())
+				}
+			case dto.MetricType_HISTOGRAM:
+				addField("count", float64(m.GetHistogram().GetSampleCount()))
+				addField("sum", m.GetHistogram().GetSampleSum())
+				for _, b := range m.GetHistogram().GetBucket() {
+					if !math.IsInf(b.GetUpperBound(), 1) {
+						addField(formatFloat(b.GetUpperBound()), float64(b.GetCumulativeCount()))
+					}
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			// tags are sorted by their name for the best performance of influx
+			labels := m.GetLabel()
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].GetName() < labels[j].GetName()
+			})
+
+			buf.WriteString(influxMeasurementEscaper.Replace(mf.GetName()))
+			for _, l := range labels {
+				if l.GetValue() == "" {
+					continue
+				}
+				buf.WriteString("," + influxTagEscaper.Replace(l.GetName()) + "=" + influxTagEscaper.Replace(l.GetValue()))
+			}
+			buf.WriteString(" " + strings.Join(fields, ",") + " " + ts + "\n")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func testFormatsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	keys := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_db_keys", Help: "keys"}, []string{"db", "graph"})
+	keys.WithLabelValues("db0", "social, network").Set(42)
+	keys.WithLabelValues("db1", "").Set(math.NaN())
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_latency", Help: "latency", Buckets: []float64{1, 10}})
+	latency.Observe(5)
+	registry.MustRegister(keys, latency)
+	return registry
+}
+
+func TestMetricsHandlerFormats(t *testing.T) {
+	handler := metricsHandler(testFormatsRegistry(), false)
+
+	for _, tst := range []struct {
+		query       string
+		accept      string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{query: "", status: http.StatusOK, contentType: "text/plain", contains: `test_db_keys{db="db0",graph="social, network"} 42`},
+		{query: "?format=prometheus", status: http.StatusOK, contentType: "text/plain", contains: `test_latency_bucket{le="10"} 1`},
+		{query: "?format=json", status: http.StatusOK, contentType: "application/json", contains: `"name":"test_db_keys"`},
+		{query: "", accept: "application/json", status: http.StatusOK, contentType: "application/json", contains: `"value":"NaN"`},
+		{query: "?format=influx", status: http.StatusOK, contentType: "text/plain", contains: `test_db_keys,db=db0,graph=social\,\ network value=42 `},
+		{query: "?format=xml", status: http.StatusBadRequest, contains: "Unsupported format"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics"+tst.query, nil)
+		if tst.accept != "" {
+			req.Header.Set("Accept", tst.accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tst.status {
+			t.Errorf("%s: expected status %d, got: %d", tst.query, tst.status, w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), tst.contentType) {
+			t.Errorf("%s: unexpected content type: %s", tst.query, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), tst.contains) {
+			t.Errorf("%s: expected %q in body, got: %s", tst.query, tst.contains, w.Body.String())
+		}
+	}
+}
+
+func TestMetricFamiliesToJSON(t *testing.T) {
+	families, err := testFormatsRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() err: %s", err)
+	}
+	data, err := json.Marshal(metricFamiliesToJSON(families))
+	if err != nil {
+		t.Fatalf("json.Marshal() err: %s", err)
+	}
+
+	var res []struct {
+		Name    string
+		Type    string
+		Metrics []struct {
+			Labels  map[string]string
+			Value   any
+			Count   uint64
+			Sum     float64
+			Buckets map[string]uint64
+		}
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("json.Unmarshal() err: %s", err)
+	}
+	if len(res) != 2 || res[0].Name != "test_db_keys" || res[0].Type != "gauge" || res[1].Type != "histogram" {
+		t.Fatalf("unexpected families: %s", data)
+	}
+	if m := res[0].Metrics[0]; m.Labels["db"] != "db0" || m.Labels["graph"] != "social, network" || m.Value != 42.0 {
+		t.Errorf("unexpected metric: %#v", m)
+	}
+	if m := res[0].Metrics[1]; m.Value != "NaN" {
+		t.Errorf("expected NaN as string, got: %#v", m.Value)
+	}
+	if m := res[1].Metrics[0]; m.Count != 1 || m.Sum != 5 || m.Buckets["1"] != 0 || m.Buckets["10"] != 1 || m.Buckets["+Inf"] != 1 {
+		t.Errorf("unexpected histogram: %#v", m)
+	}
+}
+
+func TestWriteInfluxLineProtocol(t *testing.T) {
+	families, err := testFormatsRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() err: %s", err)
+	}
+	var buf bytes.Buffer
+	writeInfluxLineProtocol(&buf, families, time.Unix(1700000000, 0))
+
+	want := `test_db_keys,db=db0,graph=social\,\ network value=42 1700000000000000000
+test_latency count=1,sum=5,1=0,10=1 1700000000000000000
+`
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
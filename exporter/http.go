@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -65,6 +64,28 @@ func (e *Exporter) scrapeHandler(w http.ResponseWriter, r *http.Request) {
 
 	opts := e.options
 
+	// background subscribers are bound to the exporter's own redis instance,
+	// starting them for the short-lived exporters of /scrape requests would leak them
+	opts.SentinelSubscribeEvents = false
+	opts.KeyspaceEvents = ""
+
+	// the push loops would keep pushing the metrics of the target long after the request
+	opts.OtlpEndpoint = ""
+	opts.RemoteWriteURL = ""
+	opts.StatsdAddress = ""
+	opts.GraphiteAddress = ""
+
+	// the big keys and key groups scans, the LATENCY HISTORY watermarks, the connection churn tracking, the stream
+	// production rate, the ACL LOG counters and the sentinel failover history carry state across scrapes, which
+	// the exporters of /scrape requests don't live long enough for
+	opts.CheckBigKeysBudget = 0
+	opts.KeyGroupsScanInterval = 0
+	opts.InclLatencyHistory = false
+	opts.ExportClientChurn = false
+	opts.StreamsExcludeProductionRate = true
+	opts.ExcludeAclLogCounters = true
+	opts.ExcludeSentinelFailoverMetrics = true
+
 	// get rid of username/password info in "target" so users don't send them in plain text via http
 	// and save "user" in options so we can use it later when connecting to the redis instance
 	// the password will be looked up from the password file
@@ -94,6 +115,10 @@ func (e *Exporter) scrapeHandler(w http.ResponseWriter, r *http.Request) {
 		opts.CountKeys = cntk
 	}
 
+	if ckd := r.URL.Query().Get("check-key-distributions"); ckd != "" {
+		opts.CheckKeyDistributions = ckd
+	}
+
 	opts.Registry = prometheus.NewRegistry()
 
 	_, err = NewRedisExporter(target, opts)
@@ -103,9 +128,7 @@ func (e *Exporter) scrapeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	promhttp.HandlerFor(
-		opts.Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError},
-	).ServeHTTP(w, r)
+	metricsHandler(opts.Registry, opts.InclSlowlogExemplars).ServeHTTP(w, r)
 }
 
 func (e *Exporter) discoverClusterNodesHandler(w http.ResponseWriter, r *http.Request) {
@@ -156,6 +179,34 @@ func (e *Exporter) discoverClusterNodesHandler(w http.ResponseWriter, r *http.Re
 	_, _ = w.Write(data)
 }
 
+func (e *Exporter) replicationTopologyHandler(w http.ResponseWriter, r *http.Request) {
+	if !e.options.InclReplicationTopology {
+		http.Error(w, "The replication topology endpoint is only available with include-replication-topology", http.StatusBadRequest)
+		return
+	}
+
+	addr, err := redisHostPort(e.redisAddr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Couldn't parse the address of the redis instance: %s", e.redisAddr), http.StatusInternalServerError)
+		return
+	}
+
+	topology, err := getReplicationTopology(addr, e.connectToRedisNode)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch the replication topology: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.MarshalIndent(topology, "", "  ")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to marshal replication topology: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
 func (e *Exporter) reloadPwdFile(w http.ResponseWriter, r *http.Request) {
 	if e.options.RedisPwdFile == "" {
 		http.Error(w, "There is no pwd file specified", http.StatusBadRequest)
@@ -0,0 +1,187 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// keyTypeSizeCommands maps the key types supported by SCAN ... TYPE to the command returning their length/size
+var keyTypeSizeCommands = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+var (
+	keySizeDistributionBuckets        = prometheus.ExponentialBuckets(1, 10, 8)  // 1 .. 10M elements
+	keyMemoryUsageDistributionBuckets = prometheus.ExponentialBuckets(64, 4, 12) // 64B .. 256MB
+)
+
+// histogramAccumulator collects observations for a const histogram
+type histogramAccumulator struct {
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogramAccumulator(upperBounds []float64) *histogramAccumulator {
+	return &histogramAccumulator{upperBounds: upperBounds, counts: make([]uint64, len(upperBounds))}
+}
+
+func (h *histogramAccumulator) observe(v float64) {
+	h.count++
+	h.sum += v
+	for i, b := range h.upperBounds {
+		if v <= b {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// buckets returns the cumulative bucket counts as expected by prometheus.NewConstHistogram
+func (h *histogramAccumulator) buckets() map[float64]uint64 {
+	res := make(map[float64]uint64, len(h.upperBounds))
+	var cumulative uint64
+	for i, b := range h.upperBounds {
+		cumulative += h.counts[i]
+		res[b] = cumulative
+	}
+	return res
+}
+
+// parseKeyDistributionTypes returns the key types to run SCAN ... TYPE for, defaults to all supported types
+func parseKeyDistributionTypes(typesArg string) ([]string, error) {
+	var types []string
+	for t := range strings.SplitSeq(typesArg, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if _, ok := keyTypeSizeCommands[t]; !ok {
+			return nil, fmt.Errorf("unsupported key type: %s", t)
+		}
+		types = append(types, t)
+	}
+	if len(types) == 0 {
+		return []string{"string", "list", "set", "zset", "hash", "stream"}, nil
+	}
+	return types, nil
+}
+
+func (e *Exporter) extractKeyDistributionMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	patterns, err := parseKeyArg(e.options.CheckKeyDistributions)
+	if err != nil {
+		log.Errorf("Couldn't parse check-key-distributions: %s", err)
+		return
+	}
+	if len(patterns) == 0 {
+		return
+	}
+
+	keyTypes, err := parseKeyDistributionTypes(e.options.CheckKeyDistributionTypes)
+	if err != nil {
+		log.Errorf("Couldn't parse check-key-distribution-types: %s", err)
+		return
+	}
+
+	for _, k := range patterns {
+		if !e.options.IsCluster {
+			if _, err := doRedisCmd(c, "SELECT", k.db); err != nil {
+				log.Errorf("Couldn't select database '%s' when getting key distributions", k.db)
+				continue
+			}
+		}
+		dbLabel := "db" + k.db
+
+		for _, keyType := range keyTypes {
+			sizes := newHistogramAccumulator(keySizeDistributionBuckets)
+			memoryUsage := newHistogramAccumulator(keyMemoryUsageDistributionBuckets)
+
+			if err := e.scanKeyDistribution(c, k.key, keyType, sizes, memoryUsage); err != nil {
+				log.Errorf("couldn't get key distribution for pattern '%s' and type '%s', err: %s", k.key, keyType, err)
+				continue
+			}
+
+			e.registerConstHistogram(ch, "key_pattern_size", sizes.count, sizes.sum, sizes.buckets(), dbLabel, k.key, keyType)
+			e.registerConstHistogram(ch, "key_pattern_memory_usage_bytes", memoryUsage.count, memoryUsage.sum, memoryUsage.buckets(), dbLabel, k.key, keyType)
+		}
+	}
+}
+
+// scanKeyDistribution runs SCAN ... MATCH pattern TYPE keyType and observes the size and memory usage
+// of every batch of keys returned by SCAN, so the keys never have to be held in memory all at once.
+func (e *Exporter) scanKeyDistribution(c redis.Conn, pattern string, keyType string, sizes *histogramAccumulator, memoryUsage *histogramAccumulator) error {
+	sizeCmd := keyTypeSizeCommands[keyType]
+
+	cursor := 0
+	for {
+		arr, err := redis.Values(doRedisCmd(c, "SCAN", cursor, "MATCH", pattern, "COUNT", e.options.CheckKeysBatchSize, "TYPE", keyType))
+		if err != nil {
+			return err
+		}
+		if len(arr) != 2 {
+			return fmt.Errorf("invalid response from SCAN for pattern: %s", pattern)
+		}
+		keys, _ := redis.Strings(arr[1], nil)
+
+		if err := e.observeKeySizesAndMemoryUsage(c, sizeCmd, keys, sizes, memoryUsage); err != nil {
+			return err
+		}
+
+		if cursor, _ = redis.Int(arr[0], nil); cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func (e *Exporter) observeKeySizesAndMemoryUsage(c redis.Conn, sizeCmd string, keys []string, sizes *histogramAccumulator, memoryUsage *histogramAccumulator) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// no pipelining / batching for cluster mode, it's not supported
+	if e.options.IsCluster {
+		for _, key := range keys {
+			size, sizeErr := redis.Int64(doRedisCmd(c, sizeCmd, key))
+			usage, usageErr := redis.Int64(doRedisCmd(c, "MEMORY", "USAGE", key))
+			if sizeErr != nil || usageErr != nil {
+				continue
+			}
+			sizes.observe(float64(size))
+			memoryUsage.observe(float64(usage))
+		}
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := c.Send(sizeCmd, key); err != nil {
+			return err
+		}
+		if err := c.Send("MEMORY", "USAGE", key); err != nil {
+			return err
+		}
+	}
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	for range keys {
+		size, sizeErr := redis.Int64(c.Receive())
+		usage, usageErr := redis.Int64(c.Receive())
+		// MEMORY USAGE returns nil for keys that were deleted after SCAN returned them
+		if sizeErr != nil || usageErr != nil {
+			continue
+		}
+		sizes.observe(float64(size))
+		memoryUsage.observe(float64(usage))
+	}
+	return nil
+}
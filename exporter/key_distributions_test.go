@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestKeyDistributionsHistogramAccumulator(t *testing.T) {
+	h := newHistogramAccumulator([]float64{1, 10, 100})
+	for _, v := range []float64{0, 1, 5, 50, 500} {
+		h.observe(v)
+	}
+
+	if h.count != 5 || h.sum != 556 {
+		t.Errorf("unexpected count/sum: %d / %f", h.count, h.sum)
+	}
+	buckets := h.buckets()
+	for b, want := range map[float64]uint64{1: 2, 10: 3, 100: 4} {
+		if buckets[b] != want {
+			t.Errorf("bucket %f: want %d, got %d", b, want, buckets[b])
+		}
+	}
+}
+
+func TestKeyDistributionsParseTypes(t *testing.T) {
+	for _, tst := range []struct {
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{arg: "", want: "string,list,set,zset,hash,stream"},
+		{arg: "hash, ZSET", want: "hash,zset"},
+		{arg: "hash,json", wantErr: true},
+	} {
+		t.Run(tst.arg, func(t *testing.T) {
+			types, err := parseKeyDistributionTypes(tst.arg)
+			if tst.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", tst.arg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %s", err)
+			}
+			if got := strings.Join(types, ","); got != tst.want {
+				t.Errorf("want: %s, got: %s", tst.want, got)
+			}
+		})
+	}
+
+	if _, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckKeyDistributionTypes: "json"}); err == nil {
+		t.Errorf("expected NewRedisExporter() to fail for an unsupported key type")
+	}
+}
+
+func TestKeyDistributionsMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", IsCluster: true, CheckKeysBatchSize: 10, CheckKeyDistributions: "db0=user:*", CheckKeyDistributionTypes: "hash"})
+
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch cmd {
+		case "SCAN":
+			if args[0] == 0 {
+				return []any{[]byte("7"), []any{[]byte("user:1"), []byte("user:2")}}, nil
+			}
+			return []any{[]byte("0"), []any{[]byte("user:gone")}}, nil
+		case "HLEN":
+			if args[0] == "user:gone" {
+				return int64(0), nil
+			}
+			return int64(5), nil
+		case "MEMORY":
+			if args[1] == "user:gone" {
+				return nil, nil
+			}
+			return int64(100), nil
+		}
+		t.Errorf("unexpected command: %s", cmd)
+		return nil, nil
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractKeyDistributionMetrics(chM, c)
+		close(chM)
+	}()
+
+	found := map[string]bool{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		h := d.GetHistogram()
+		switch {
+		case strings.Contains(m.Desc().String(), "key_pattern_size"):
+			found["size"] = true
+			if h.GetSampleCount() != 2 || h.GetSampleSum() != 10 {
+				t.Errorf("unexpected size histogram: %v", h)
+			}
+		case strings.Contains(m.Desc().String(), "key_pattern_memory_usage_bytes"):
+			found["memory"] = true
+			if h.GetSampleCount() != 2 || h.GetSampleSum() != 200 {
+				t.Errorf("unexpected memory usage histogram: %v", h)
+			}
+		}
+		for _, l := range d.GetLabel() {
+			if l.GetName() == "type" && l.GetValue() != "hash" {
+				t.Errorf("unexpected type label: %s", l.GetValue())
+			}
+		}
+	}
+
+	if !found["size"] || !found["memory"] {
+		t.Errorf("missing histograms, found: %v", found)
+	}
+}
+
+func TestKeyDistributionsLive(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_URI")
+	if addr == "" {
+		t.Skipf("TEST_REDIS_URI not set - skipping")
+	}
+
+	c, err := redis.DialURL(addr)
+	if err != nil {
+		t.Fatalf("couldn't setup redis, err: %s", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Do("SELECT", dbNumStr); err != nil {
+		t.Fatalf("couldn't select db, err: %s", err)
+	}
+	key := "key-distributions-test"
+	if _, err := c.Do("RPUSH", key, "a", "b", "c"); err != nil {
+		t.Fatalf("couldn't setup redis, err: %s", err)
+	}
+	defer c.Do("DEL", key)
+
+	e, _ := NewRedisExporter(addr, Options{Namespace: "test", CheckKeysBatchSize: 1000, CheckKeyDistributions: dbNumStrFull + "=" + key, CheckKeyDistributionTypes: "list"})
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractKeyDistributionMetrics(chM, c)
+		close(chM)
+	}()
+
+	found := false
+	for m := range chM {
+		if !strings.Contains(m.Desc().String(), "key_pattern_size") {
+			continue
+		}
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		if d.GetHistogram().GetSampleCount() == 1 && d.GetHistogram().GetSampleSum() == 3 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("didn't find the key_pattern_size histogram for %s", key)
+	}
+}
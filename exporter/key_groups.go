@@ -3,6 +3,7 @@ package exporter
 import (
 	"encoding/csv"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -31,10 +32,19 @@ type keyGroupsScrapeResult struct {
 }
 
 func (e *Exporter) extractKeyGroupMetrics(ch chan<- prometheus.Metric, c redis.Conn, dbCount int) {
+	if e.options.KeyGroupsScanInterval > 0 {
+		e.extractKeyGroupMetricsFromBackgroundScan(ch, dbCount)
+		return
+	}
 	allDbKeyGroupMetrics := e.gatherKeyGroupsMetricsForAllDatabases(c, dbCount)
 	if allDbKeyGroupMetrics == nil {
 		return
 	}
+	e.registerKeyGroupsScrapeResult(ch, allDbKeyGroupMetrics)
+	e.registerConstMetricGauge(ch, "last_key_groups_scrape_duration_milliseconds", float64(allDbKeyGroupMetrics.duration.Milliseconds()))
+}
+
+func (e *Exporter) registerKeyGroupsScrapeResult(ch chan<- prometheus.Metric, allDbKeyGroupMetrics *keyGroupsScrapeResult) {
 	for db, dbKeyGroupMetrics := range allDbKeyGroupMetrics.metrics {
 		dbLabel := fmt.Sprintf("db%d", db)
 		registerKeyGroupMetrics := func(metrics *keyGroupMetrics) {
@@ -67,7 +77,118 @@ func (e *Exporter) extractKeyGroupMetrics(ch chan<- prometheus.Metric, c redis.C
 			e.registerConstMetricGauge(ch, "number_of_distinct_key_groups", float64(len(dbKeyGroupMetrics)), dbLabel)
 		}
 	}
-	e.registerConstMetricGauge(ch, "last_key_groups_scrape_duration_milliseconds", float64(allDbKeyGroupMetrics.duration.Milliseconds()))
+}
+
+// keyGroupsConfig holds the lua regexes for classifying keys into groups and the settings
+// for the automatic grouping by key prefix of the keys not matching any of the regexes
+type keyGroupsConfig struct {
+	regexes       []string
+	autoDelimiter string
+	autoDepth     int64
+}
+
+func (k keyGroupsConfig) enabled() bool {
+	return len(k.regexes) > 0 || k.autoDepth > 0
+}
+
+func (k keyGroupsConfig) String() string {
+	if k.autoDepth > 0 {
+		return fmt.Sprintf("%s (auto grouping by %d %q separated segments)", strings.Join(k.regexes, ", "), k.autoDepth, k.autoDelimiter)
+	}
+	return strings.Join(k.regexes, ", ")
+}
+
+// validate checks the lua regexes like the key groups lua script does before classifying the keys
+func (k keyGroupsConfig) validate() error {
+	for _, regex := range k.regexes {
+		if _, _, err := luaPatternFind(" ", regex); err != nil {
+			return fmt.Errorf("invalid key group regex %s: %w", regex, err)
+		}
+	}
+	return nil
+}
+
+// keyGroup is the Go version of the classification of a key in the key groups lua script: the
+// concatenated captures of the first matching regex, else the auto group or "unclassified"
+func (k keyGroupsConfig) keyGroup(key string) string {
+	for _, regex := range k.regexes {
+		if captures, matched, err := luaPatternFind(key, regex); err == nil && matched {
+			return strings.Join(captures, "")
+		}
+	}
+	if k.autoDepth > 0 {
+		return autoKeyGroup(key, k.autoDelimiter, k.autoDepth)
+	}
+	return "unclassified"
+}
+
+func (e *Exporter) keyGroupsConfig() (keyGroupsConfig, error) {
+	return newKeyGroupsConfig(e.options)
+}
+
+// newKeyGroupsConfig parses and validates the check-key-groups options, shared by the exporter and AnalyzeRDB
+func newKeyGroupsConfig(options Options) (keyGroupsConfig, error) {
+	regexes, err := parseKeyGroups(options.CheckKeyGroups)
+	if err != nil {
+		return keyGroupsConfig{}, fmt.Errorf("couldn't parse check-key-groups: %w", err)
+	}
+	if options.KeyGroupsAutoDepth > 0 && options.KeyGroupsAutoDelimiter == "" {
+		return keyGroupsConfig{}, fmt.Errorf("check-key-groups-auto-delimiter can't be empty when check-key-groups-auto-depth is set")
+	}
+	k := keyGroupsConfig{
+		regexes:       regexes,
+		autoDelimiter: options.KeyGroupsAutoDelimiter,
+		autoDepth:     options.KeyGroupsAutoDepth,
+	}
+	if err := k.validate(); err != nil {
+		return keyGroupsConfig{}, err
+	}
+	return k, nil
+}
+
+// parseKeyGroups returns the non-empty lua regexes of the comma separated check-key-groups argument
+func parseKeyGroups(keyGroupsArg string) ([]string, error) {
+	if strings.TrimSpace(keyGroupsArg) == "" {
+		return nil, nil
+	}
+	keyGroups, err := csv.NewReader(
+		strings.NewReader(keyGroupsArg),
+	).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	keyGroupsNoEmptyStrings := make([]string, 0)
+	for _, v := range keyGroups {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			keyGroupsNoEmptyStrings = append(keyGroupsNoEmptyStrings, v)
+		}
+	}
+	return keyGroupsNoEmptyStrings, nil
+}
+
+var (
+	keyGroupUUIDSegment = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	keyGroupHexSegment  = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+	keyGroupNumSegment  = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// autoKeyGroup is the Go version of auto_group() in the key groups lua script, it groups keys by their
+// first depth segments with numeric, UUID and hex segments replaced by placeholders.
+func autoKeyGroup(key string, delimiter string, depth int64) string {
+	segments := strings.SplitN(key, delimiter, int(depth)+1)
+	segments = segments[:min(len(segments), int(depth))]
+	for i, segment := range segments {
+		switch {
+		case keyGroupNumSegment.MatchString(segment):
+			segments[i] = "<num>"
+		case keyGroupUUIDSegment.MatchString(segment):
+			segments[i] = "<uuid>"
+		case keyGroupHexSegment.MatchString(segment) && strings.ContainsAny(segment, "0123456789"):
+			segments[i] = "<hex>"
+		}
+	}
+	return strings.Join(segments, delimiter)
 }
 
 func (e *Exporter) gatherKeyGroupsMetricsForAllDatabases(c redis.Conn, dbCount int) *keyGroupsScrapeResult {
@@ -79,27 +200,12 @@ func (e *Exporter) gatherKeyGroupsMetricsForAllDatabases(c redis.Conn, dbCount i
 	defer func() {
 		allMetrics.duration = time.Since(start)
 	}()
-	if strings.TrimSpace(e.options.CheckKeyGroups) == "" {
-		return allMetrics
-	}
-	keyGroups, err := csv.NewReader(
-		strings.NewReader(e.options.CheckKeyGroups),
-	).Read()
+	keyGroups, err := e.keyGroupsConfig()
 	if err != nil {
 		log.Errorf("Failed to parse key groups as csv: %s", err)
 		return allMetrics
 	}
-	for i, v := range keyGroups {
-		keyGroups[i] = strings.TrimSpace(v)
-	}
-
-	keyGroupsNoEmptyStrings := make([]string, 0)
-	for _, v := range keyGroups {
-		if len(v) > 0 {
-			keyGroupsNoEmptyStrings = append(keyGroupsNoEmptyStrings, v)
-		}
-	}
-	if len(keyGroupsNoEmptyStrings) == 0 {
+	if !keyGroups.enabled() {
 		return allMetrics
 	}
 	for db := range dbCount {
@@ -107,57 +213,85 @@ func (e *Exporter) gatherKeyGroupsMetricsForAllDatabases(c redis.Conn, dbCount i
 			log.Errorf("Couldn't select database %d when getting key info.", db)
 			continue
 		}
-		allGroups, err := gatherKeyGroupMetrics(c, e.options.CheckKeysBatchSize, keyGroupsNoEmptyStrings)
+		allGroups, err := gatherKeyGroupMetrics(c, e.options.CheckKeysBatchSize, keyGroups)
 		if err != nil {
 			log.Error(err)
 			continue
 		}
 		allMetrics.metrics[db] = allGroups
-		if int64(len(allGroups)) > e.options.MaxDistinctKeyGroups {
-			metricsSlice := make([]*keyGroupMetrics, 0, len(allGroups))
-			for _, v := range allGroups {
-				metricsSlice = append(metricsSlice, v)
-			}
-			sort.Slice(metricsSlice, func(i, j int) bool {
-				if metricsSlice[i].memoryUsage == metricsSlice[j].memoryUsage {
-					if metricsSlice[i].count == metricsSlice[j].count {
-						return metricsSlice[i].keyGroup < metricsSlice[j].keyGroup
-					}
-					return metricsSlice[i].count < metricsSlice[j].count
-				}
-				return metricsSlice[i].memoryUsage > metricsSlice[j].memoryUsage
-			})
-			var overflowedCount, overflowedMemoryUsage int64
-			for _, v := range metricsSlice[e.options.MaxDistinctKeyGroups:] {
-				overflowedCount += v.count
-				overflowedMemoryUsage += v.memoryUsage
-			}
-			allMetrics.overflowedMetrics[db] = &overflowedKeyGroupMetrics{
-				topMemoryUsageKeyGroups: metricsSlice[:e.options.MaxDistinctKeyGroups],
-				overflowKeyGroupAggregate: keyGroupMetrics{
-					keyGroup:    "overflow",
-					count:       overflowedCount,
-					memoryUsage: overflowedMemoryUsage,
-				},
-				keyGroupsCount: int64(len(allGroups)),
-			}
-		}
+		allMetrics.overflowedMetrics[db] = overflowKeyGroups(allGroups, e.options.MaxDistinctKeyGroups)
 	}
 	return allMetrics
 }
 
-func gatherKeyGroupMetrics(c redis.Conn, batchSize int64, keyGroups []string) (map[string]*keyGroupMetrics, error) {
-	allGroups := make(map[string]*keyGroupMetrics)
-	keysAndArgs := []any{0, batchSize}
-	for _, keyGroup := range keyGroups {
-		keysAndArgs = append(keysAndArgs, keyGroup)
+// overflowKeyGroups keeps the maxDistinctKeyGroups key groups with the highest memory usage and aggregates
+// the remaining ones into the "overflow" key group, returns nil if there are no more than maxDistinctKeyGroups
+func overflowKeyGroups(allGroups map[string]*keyGroupMetrics, maxDistinctKeyGroups int64) *overflowedKeyGroupMetrics {
+	if int64(len(allGroups)) <= maxDistinctKeyGroups {
+		return nil
+	}
+	metricsSlice := make([]*keyGroupMetrics, 0, len(allGroups))
+	for _, v := range allGroups {
+		metricsSlice = append(metricsSlice, v)
+	}
+	sort.Slice(metricsSlice, func(i, j int) bool {
+		if metricsSlice[i].memoryUsage == metricsSlice[j].memoryUsage {
+			if metricsSlice[i].count == metricsSlice[j].count {
+				return metricsSlice[i].keyGroup < metricsSlice[j].keyGroup
+			}
+			return metricsSlice[i].count < metricsSlice[j].count
+		}
+		return metricsSlice[i].memoryUsage > metricsSlice[j].memoryUsage
+	})
+	var overflowedCount, overflowedMemoryUsage int64
+	for _, v := range metricsSlice[maxDistinctKeyGroups:] {
+		overflowedCount += v.count
+		overflowedMemoryUsage += v.memoryUsage
+	}
+	return &overflowedKeyGroupMetrics{
+		topMemoryUsageKeyGroups: metricsSlice[:maxDistinctKeyGroups],
+		overflowKeyGroupAggregate: keyGroupMetrics{
+			keyGroup:    "overflow",
+			count:       overflowedCount,
+			memoryUsage: overflowedMemoryUsage,
+		},
+		keyGroupsCount: int64(len(allGroups)),
 	}
+}
 
-	script := redis.NewScript(
-		0,
-		`
+var keyGroupsScript = redis.NewScript(
+	0,
+	`
+local function normalize_segment(segment)
+  if string.find(segment, "^%d+$") then
+    return "<num>"
+  end
+  if string.find(segment, "^%x%x%x%x%x%x%x%x%-%x%x%x%x%-%x%x%x%x%-%x%x%x%x%-%x%x%x%x%x%x%x%x%x%x%x%x$") then
+    return "<uuid>"
+  end
+  if #segment >= 8 and string.find(segment, "^%x+$") and string.find(segment, "%d") then
+    return "<hex>"
+  end
+  return segment
+end
+local function auto_group(key, delimiter, depth)
+  local segments = {}
+  local start = 1
+  while #segments < depth do
+    local from, to = string.find(key, delimiter, start, true)
+    if from == nil then
+      segments[#segments+1] = normalize_segment(string.sub(key, start))
+      break
+    end
+    segments[#segments+1] = normalize_segment(string.sub(key, start, from - 1))
+    start = to + 1
+  end
+  return table.concat(segments, delimiter)
+end
 local result = {}
 local batch = redis.call("SCAN", ARGV[1], "COUNT", ARGV[2])
+local auto_delimiter = ARGV[3]
+local auto_depth = tonumber(ARGV[4])
 local groups = {}
 local usage = 0
 local group_index = 0
@@ -166,7 +300,7 @@ local value = {}
 local key_match_result = {}
 local status = false
 local err = nil
-for i=3,#ARGV do
+for i=5,#ARGV do
   status, err = pcall(string.find, " ", ARGV[i])
   if not status then
     error(err .. ARGV[i])
@@ -178,13 +312,16 @@ for i,key in ipairs(batch[2]) do
     usage = reply;
   end
   group = nil
-  for i=3,#ARGV do
+  for i=5,#ARGV do
     key_match_result = {string.find(key, ARGV[i])}
     if key_match_result[1] ~= nil then
       group = table.concat({unpack(key_match_result, 3,  #key_match_result)},  "")
       break
     end
   end
+  if group == nil and auto_depth > 0 then
+     group = auto_group(key, auto_delimiter, auto_depth)
+  end
   if group == nil then
      group = "unclassified"
   end
@@ -199,41 +336,60 @@ for group,value in pairs(groups) do
   result[#result+1] = {group, value[1], value[2]}
 end
 return {batch[1], result}`,
-	)
+)
 
+func gatherKeyGroupMetrics(c redis.Conn, batchSize int64, keyGroups keyGroupsConfig) (map[string]*keyGroupMetrics, error) {
+	allGroups := make(map[string]*keyGroupMetrics)
+	cursor := 0
 	for {
-		arr, err := redis.Values(script.Do(c, keysAndArgs...))
-		if err != nil {
+		var err error
+		if cursor, _, err = gatherKeyGroupMetricsBatch(c, cursor, batchSize, keyGroups, allGroups); err != nil {
 			return nil, err
 		}
-
-		if len(arr) != 2 {
-			return nil, fmt.Errorf("invalid response from key group metrics lua script for groups: %s", strings.Join(keyGroups, ", "))
+		if cursor == 0 {
+			break
 		}
+	}
+	return allGroups, nil
+}
 
-		groups, _ := redis.Values(arr[1], nil)
-
-		for _, group := range groups {
-			metricsArr, _ := redis.Values(group, nil)
-			name, _ := redis.String(metricsArr[0], nil)
-			count, _ := redis.Int64(metricsArr[1], nil)
-			memoryUsage, _ := redis.Int64(metricsArr[2], nil)
-
-			if currentMetrics, ok := allGroups[name]; ok {
-				currentMetrics.count += count
-				currentMetrics.memoryUsage += memoryUsage
-			} else {
-				allGroups[name] = &keyGroupMetrics{
-					keyGroup:    name,
-					count:       count,
-					memoryUsage: memoryUsage,
-				}
-			}
+// gatherKeyGroupMetricsBatch runs the key groups lua script for a single SCAN batch starting at cursor,
+// adds the results to allGroups and returns the next cursor and the number of keys in the batch.
+func gatherKeyGroupMetricsBatch(c redis.Conn, cursor int, batchSize int64, keyGroups keyGroupsConfig, allGroups map[string]*keyGroupMetrics) (nextCursor int, keysCount int64, err error) {
+	keysAndArgs := []any{cursor, batchSize, keyGroups.autoDelimiter, keyGroups.autoDepth}
+	for _, keyGroup := range keyGroups.regexes {
+		keysAndArgs = append(keysAndArgs, keyGroup)
+	}
 
-		}
-		if keysAndArgs[0], _ = redis.Int(arr[0], nil); keysAndArgs[0].(int) == 0 {
-			break
+	arr, err := redis.Values(keyGroupsScript.Do(c, keysAndArgs...))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(arr) != 2 {
+		return 0, 0, fmt.Errorf("invalid response from key group metrics lua script for groups: %s", keyGroups)
+	}
+
+	groups, _ := redis.Values(arr[1], nil)
+
+	for _, group := range groups {
+		metricsArr, _ := redis.Values(group, nil)
+		name, _ := redis.String(metricsArr[0], nil)
+		count, _ := redis.Int64(metricsArr[1], nil)
+		memoryUsage, _ := redis.Int64(metricsArr[2], nil)
+		keysCount += count
+
+		if currentMetrics, ok := allGroups[name]; ok {
+			currentMetrics.count += count
+			currentMetrics.memoryUsage += memoryUsage
+		} else {
+			allGroups[name] = &keyGroupMetrics{
+				keyGroup:    name,
+				count:       count,
+				memoryUsage: memoryUsage,
+			}
 		}
 	}
-	return allGroups, nil
+	nextCursor, _ = redis.Int(arr[0], nil)
+	return nextCursor, keysCount, nil
 }
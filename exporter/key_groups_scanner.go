@@ -0,0 +1,193 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// keyGroupsBackgroundScan is the state of the incremental key groups scan, it's advanced by a background
+// goroutine every KeyGroupsScanInterval and read by the scrapes.
+type keyGroupsBackgroundScan struct {
+	sync.Mutex
+
+	dbCount int
+	db      int
+	cursor  int
+
+	// the pass in progress
+	passStart       time.Time
+	passKeysTotal   int64
+	passKeysScanned int64
+	current         []map[string]*keyGroupMetrics
+
+	// the last complete pass, nil until the first pass is complete
+	result *keyGroupsScrapeResult
+	passes float64
+}
+
+func newKeyGroupsBackgroundScan(dbCount int) *keyGroupsBackgroundScan {
+	return &keyGroupsBackgroundScan{dbCount: dbCount}
+}
+
+func (e *Exporter) extractKeyGroupMetricsFromBackgroundScan(ch chan<- prometheus.Metric, dbCount int) {
+	e.keyGroupsScanOnce.Do(func() {
+		keyGroups, err := e.keyGroupsConfig()
+		if err != nil {
+			log.Errorf("key groups background scan err: %s", err)
+			return
+		}
+		if !keyGroups.enabled() {
+			return
+		}
+		e.keyGroupsScan = newKeyGroupsBackgroundScan(dbCount)
+		go e.runKeyGroupsBackgroundScan(keyGroups)
+	})
+	s := e.keyGroupsScan
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.result != nil {
+		e.registerKeyGroupsScrapeResult(ch, s.result)
+		e.registerConstMetricGauge(ch, "key_groups_scan_last_pass_duration_seconds", s.result.duration.Seconds())
+	}
+	e.registerConstMetric(ch, "key_groups_scan_passes_total", s.passes, prometheus.CounterValue)
+	e.registerConstMetricGauge(ch, "key_groups_scan_pass_scanned_keys", float64(s.passKeysScanned))
+
+	// DBSIZE at the start of the pass is only an estimate, keys might be added while the pass is in progress
+	progress := 0.0
+	if s.passKeysTotal > 0 {
+		progress = min(float64(s.passKeysScanned)/float64(s.passKeysTotal), 1)
+	}
+	e.registerConstMetricGauge(ch, "key_groups_scan_pass_progress_ratio", progress)
+}
+
+func (e *Exporter) runKeyGroupsBackgroundScan(keyGroups keyGroupsConfig) {
+	ticker := time.NewTicker(e.options.KeyGroupsScanInterval)
+	defer ticker.Stop()
+
+	var c redis.Conn
+	defer func() {
+		if c != nil {
+			c.Close()
+		}
+	}()
+
+	for {
+		var err error
+		if c, err = e.keyGroupsScanTick(c, keyGroups); err != nil {
+			log.Errorf("key groups background scan err: %s", err)
+		}
+
+		select {
+		case <-e.stopCh:
+			log.Debugf("key groups background scan stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// keyGroupsScanTick advances the background scan by up to KeyGroupsScanKeysPerTick keys, the connection is
+// reused by the next tick, it's closed after an error and a new one is opened by the next tick
+func (e *Exporter) keyGroupsScanTick(c redis.Conn, keyGroups keyGroupsConfig) (redis.Conn, error) {
+	if c == nil {
+		var err error
+		if c, err = e.connectToRedis(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.keyGroupsScan.advance(c, keyGroups, e.options.KeyGroupsScanKeysPerTick, e.options.CheckKeysBatchSize, e.options.MaxDistinctKeyGroups); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *keyGroupsBackgroundScan) advance(c redis.Conn, keyGroups keyGroupsConfig, keysPerTick int64, batchSize int64, maxDistinctKeyGroups int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.current == nil {
+		if err := s.startPass(c); err != nil {
+			return err
+		}
+	}
+
+	if _, err := doRedisCmd(c, "SELECT", s.db); err != nil {
+		return err
+	}
+
+	budget := keysPerTick
+	for budget > 0 {
+		if s.current[s.db] == nil {
+			s.current[s.db] = map[string]*keyGroupMetrics{}
+		}
+		cursor, keysCount, err := gatherKeyGroupMetricsBatch(c, s.cursor, min(budget, batchSize), keyGroups, s.current[s.db])
+		if err != nil {
+			return err
+		}
+		s.cursor = cursor
+		s.passKeysScanned += keysCount
+
+		// empty batches still cost a roundtrip, so they count against the budget as well
+		budget -= max(keysCount, 1)
+
+		if s.cursor != 0 {
+			continue
+		}
+
+		s.db++
+		if s.db >= s.dbCount {
+			s.completePass(maxDistinctKeyGroups)
+			return nil
+		}
+		if _, err := doRedisCmd(c, "SELECT", s.db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *keyGroupsBackgroundScan) startPass(c redis.Conn) error {
+	s.db, s.cursor = 0, 0
+	s.passStart = time.Now()
+	s.passKeysScanned = 0
+	s.passKeysTotal = 0
+	for db := range s.dbCount {
+		if _, err := doRedisCmd(c, "SELECT", db); err != nil {
+			return err
+		}
+		size, err := redis.Int64(doRedisCmd(c, "DBSIZE"))
+		if err != nil {
+			return err
+		}
+		s.passKeysTotal += size
+	}
+	s.current = make([]map[string]*keyGroupMetrics, s.dbCount)
+	return nil
+}
+
+// completePass publishes the results of the pass, the next tick starts a new one
+func (s *keyGroupsBackgroundScan) completePass(maxDistinctKeyGroups int64) {
+	result := &keyGroupsScrapeResult{
+		duration:          time.Since(s.passStart),
+		metrics:           s.current,
+		overflowedMetrics: make([]*overflowedKeyGroupMetrics, s.dbCount),
+	}
+	for db, allGroups := range s.current {
+		result.overflowedMetrics[db] = overflowKeyGroups(allGroups, maxDistinctKeyGroups)
+	}
+	s.result = result
+	s.passes++
+	s.current = nil
+	log.Debugf("key groups background scan pass complete, took: %s", result.duration)
+}
@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeKeyGroupsConn answers the key groups lua script with two batches per database
+func fakeKeyGroupsConn(t *testing.T) *fakeConn {
+	db := 0
+	return &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch cmd {
+		case "SELECT":
+			db = args[0].(int)
+			return "OK", nil
+		case "DBSIZE":
+			return int64(4), nil
+		case "EVALSHA":
+			// args: sha, number of keys, cursor, count, auto delimiter, auto depth, key groups...
+			if args[2].(int) == 0 {
+				return []any{[]byte("17"), []any{
+					[]any{[]byte("users"), int64(2), int64(200 + db)},
+				}}, nil
+			}
+			return []any{[]byte("0"), []any{
+				[]any{[]byte("users"), int64(1), int64(100)},
+				[]any{[]byte("sessions"), int64(1), int64(50)},
+			}}, nil
+		}
+		t.Errorf("unexpected command: %s", cmd)
+		return nil, nil
+	}}
+}
+
+func TestKeyGroupsBackgroundScanAdvance(t *testing.T) {
+	s := newKeyGroupsBackgroundScan(2)
+	c := fakeKeyGroupsConn(t)
+
+	// every tick gets through a single batch
+	for tick := 1; tick <= 3; tick++ {
+		if err := s.advance(c, keyGroupsConfig{regexes: []string{"^(.*)$"}}, 2, 100, 100); err != nil {
+			t.Fatalf("advance() err: %s", err)
+		}
+		if s.result != nil {
+			t.Fatalf("results published before the pass is complete, tick: %d", tick)
+		}
+	}
+	if s.passKeysTotal != 8 || s.passKeysScanned != 6 {
+		t.Errorf("unexpected pass progress: %d / %d", s.passKeysScanned, s.passKeysTotal)
+	}
+
+	if err := s.advance(c, keyGroupsConfig{regexes: []string{"^(.*)$"}}, 2, 100, 1); err != nil {
+		t.Fatalf("advance() err: %s", err)
+	}
+	if s.result == nil || s.passes != 1 {
+		t.Fatalf("expected the pass to be complete")
+	}
+	if users := s.result.metrics[1]["users"]; users.count != 3 || users.memoryUsage != 301 {
+		t.Errorf("unexpected key group metrics for db1: %#v", users)
+	}
+	if overflow := s.result.overflowedMetrics[0]; overflow == nil || overflow.overflowKeyGroupAggregate.count != 1 {
+		t.Errorf("expected the sessions key group to overflow, got: %#v", overflow)
+	}
+
+	// the next tick starts a new pass and keeps the published results
+	if err := s.advance(c, keyGroupsConfig{regexes: []string{"^(.*)$"}}, 2, 100, 100); err != nil {
+		t.Fatalf("advance() err: %s", err)
+	}
+	if s.passKeysScanned != 2 || s.result == nil {
+		t.Errorf("unexpected state after starting a new pass, scanned: %d", s.passKeysScanned)
+	}
+}
+
+func TestKeyGroupsBackgroundScanMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckKeyGroups: "^(.*)$", KeyGroupsScanInterval: 1, MaxDistinctKeyGroups: 100})
+	s := newKeyGroupsBackgroundScan(1)
+	if err := s.advance(fakeKeyGroupsConn(t), keyGroupsConfig{regexes: []string{"^(.*)$"}}, 100, 100, 100); err != nil {
+		t.Fatalf("advance() err: %s", err)
+	}
+	e.keyGroupsScan = s
+	// don't start the background goroutine
+	e.keyGroupsScanOnce.Do(func() {})
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractKeyGroupMetrics(chM, nil, 1)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		for _, l := range d.GetLabel() {
+			if l.GetName() == "key_group" {
+				name += "/" + l.GetValue()
+			}
+		}
+		got[name] = d.GetGauge().GetValue() + d.GetCounter().GetValue()
+	}
+
+	for name, want := range map[string]float64{
+		"test_key_group_count/users":               3,
+		"test_key_group_memory_usage_bytes/users":  300,
+		"test_key_groups_scan_passes_total":        1,
+		"test_key_groups_scan_pass_progress_ratio": 1,
+	} {
+		if got[name] != want {
+			t.Errorf("metric %s: want %f, got %f", name, want, got[name])
+		}
+	}
+	if _, ok := got["test_last_key_groups_scrape_duration_milliseconds"]; ok {
+		t.Errorf("didn't expect the scrape duration metric with the background scan")
+	}
+}
+
+func TestKeyGroupsScanTickReusesConnection(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", KeyGroupsScanKeysPerTick: 2, CheckKeysBatchSize: 100, MaxDistinctKeyGroups: 100})
+	e.keyGroupsScan = newKeyGroupsBackgroundScan(2)
+	keyGroups := keyGroupsConfig{regexes: []string{"^(.*)$"}}
+
+	c := fakeKeyGroupsConn(t)
+	got, err := e.keyGroupsScanTick(c, keyGroups)
+	if err != nil || got != c {
+		t.Fatalf("expected the connection to be kept, got: %v, err: %v", got, err)
+	}
+
+	failing := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		return nil, errors.New("connection reset")
+	}}
+	if got, err := e.keyGroupsScanTick(failing, keyGroups); err == nil || got != nil {
+		t.Errorf("expected the connection to be dropped after an error, got: %v, err: %v", got, err)
+	}
+}
@@ -173,3 +173,37 @@ func TestKeyGroupMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyGroupsAutoGroupingArgs(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckKeyGroups: "^(key_ringo)_[0-9]+$", KeyGroupsAutoDelimiter: ":", KeyGroupsAutoDepth: 2})
+	if err != nil {
+		t.Fatalf("NewRedisExporter() err: %s", err)
+	}
+	keyGroups, err := e.keyGroupsConfig()
+	if err != nil || !keyGroups.enabled() {
+		t.Fatalf("expected key groups to be enabled, err: %v", err)
+	}
+
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		// args: sha, number of keys, cursor, count, auto delimiter, auto depth, key groups...
+		if cmd != "EVALSHA" || len(args) != 7 || args[4] != ":" || args[5] != int64(2) || args[6] != "^(key_ringo)_[0-9]+$" {
+			t.Errorf("unexpected command: %s %v", cmd, args)
+		}
+		return []any{[]byte("0"), []any{[]any{[]byte("user:<num>"), int64(2), int64(100)}}}, nil
+	}}
+	allGroups, err := gatherKeyGroupMetrics(c, 100, keyGroups)
+	if err != nil {
+		t.Fatalf("gatherKeyGroupMetrics() err: %s", err)
+	}
+	if g := allGroups["user:<num>"]; g == nil || g.count != 2 || g.memoryUsage != 100 {
+		t.Errorf("unexpected key groups: %#v", allGroups)
+	}
+
+	if keyGroups, _ := (&Exporter{options: Options{KeyGroupsAutoDelimiter: ":", KeyGroupsAutoDepth: 1}}).keyGroupsConfig(); !keyGroups.enabled() {
+		t.Errorf("expected automatic grouping to work without check-key-groups regexes")
+	}
+
+	if _, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", KeyGroupsAutoDepth: 2}); err == nil {
+		t.Errorf("expected an error for an empty auto grouping delimiter")
+	}
+}
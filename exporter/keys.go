@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -382,14 +383,100 @@ func (e *Exporter) extractCountKeysMetrics(ch chan<- prometheus.Metric, c redis.
 			log.Errorf("Couldn't select database '%s' when getting stream info", k.db)
 			continue
 		}
-		cnt, err := getKeysCount(c, k.key, e.options.CheckKeysBatchSize)
+		dbLabel := "db" + k.db
+		if !e.options.CountKeysInclTTL {
+			cnt, err := getKeysCount(c, k.key, e.options.CheckKeysBatchSize)
+			if err != nil {
+				log.Errorf("couldn't get key count for '%s', err: %s", k.key, err)
+				continue
+			}
+			e.registerConstMetricGauge(ch, "keys_count", float64(cnt), dbLabel, k.key)
+			continue
+		}
+
+		cnt, ttls, withoutExpiry, err := scanKeysTTLDistribution(c, k.key, e.options.CheckKeysBatchSize, !e.options.IsCluster)
 		if err != nil {
-			log.Errorf("couldn't get key count for '%s', err: %s", k.key, err)
+			log.Errorf("couldn't get key count and TTLs for '%s', err: %s", k.key, err)
 			continue
 		}
-		dbLabel := "db" + k.db
 		e.registerConstMetricGauge(ch, "keys_count", float64(cnt), dbLabel, k.key)
+		e.registerConstHistogram(ch, "keys_ttl_seconds", ttls.count, ttls.sum, ttls.buckets(), dbLabel, k.key)
+		e.registerConstMetricGauge(ch, "keys_without_expiry_count", float64(withoutExpiry), dbLabel, k.key)
+	}
+}
+
+// 1m, 5m, 15m, 1h, 6h, 1d, 7d, 30d
+var keysTTLBuckets = []float64{60, 300, 900, 3600, 21600, 86400, 604800, 2592000}
+
+/*
+scanKeysTTLDistribution runs SCAN ... MATCH pattern and the PTTL of every batch of keys returned by SCAN, so the
+keys never have to be held in memory all at once. It returns the number of matching keys, the histogram of the
+remaining TTLs and the number of keys without an expiry.
+*/
+func scanKeysTTLDistribution(c redis.Conn, pattern string, batchSize int64, pipelined bool) (keysCount int, ttls *histogramAccumulator, withoutExpiry int, err error) {
+	ttls = newHistogramAccumulator(keysTTLBuckets)
+
+	cursor := 0
+	for {
+		arr, err := redis.Values(doRedisCmd(c, "SCAN", cursor, "MATCH", pattern, "COUNT", batchSize))
+		if err != nil {
+			return keysCount, ttls, withoutExpiry, fmt.Errorf("error retrieving '%s' keys err: %s", pattern, err)
+		}
+		if len(arr) != 2 {
+			return keysCount, ttls, withoutExpiry, fmt.Errorf("invalid response from SCAN for pattern: %s", pattern)
+		}
+		keys, _ := redis.Values(arr[1], nil)
+		keysCount += len(keys)
+
+		n, err := observeKeysTTL(c, keys, batchSize, pipelined, ttls)
+		withoutExpiry += n
+		if err != nil {
+			return keysCount, ttls, withoutExpiry, err
+		}
+
+		if cursor, _ = redis.Int(arr[0], nil); cursor == 0 {
+			return keysCount, ttls, withoutExpiry, nil
+		}
+	}
+}
+
+// observeKeysTTL runs PTTL for the given keys, in batches of batchSize when pipelined, adds the
+// remaining TTLs to the histogram and returns the number of keys without an expiry.
+func observeKeysTTL(c redis.Conn, keys []any, batchSize int64, pipelined bool, ttls *histogramAccumulator) (withoutExpiry int, err error) {
+	observe := func(reply any, replyErr error) {
+		pttl, replyErr := redis.Int64(reply, replyErr)
+		switch {
+		case replyErr != nil:
+			log.Debugf("PTTL err: %s", replyErr)
+		case pttl == -1:
+			withoutExpiry++
+		case pttl >= 0:
+			ttls.observe(float64(pttl) / 1000)
+		}
+		// -2: the key was deleted after SCAN returned it
+	}
+
+	if !pipelined {
+		for _, key := range keys {
+			observe(doRedisCmd(c, "PTTL", key))
+		}
+		return withoutExpiry, nil
+	}
+
+	for batch := range slices.Chunk(keys, int(max(batchSize, 1))) {
+		for _, key := range batch {
+			if err := c.Send("PTTL", key); err != nil {
+				return withoutExpiry, err
+			}
+		}
+		if err := c.Flush(); err != nil {
+			return withoutExpiry, err
+		}
+		for range batch {
+			observe(c.Receive())
+		}
 	}
+	return withoutExpiry, nil
 }
 
 func getKeysCount(c redis.Conn, pattern string, count int64) (int, error) {
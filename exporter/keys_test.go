@@ -12,6 +12,7 @@ import (
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -829,3 +830,87 @@ func TestGetKeysCount(t *testing.T) {
 		}
 	}
 }
+
+func TestObserveKeysTTL(t *testing.T) {
+	pttls := map[string]int64{
+		"session:1": 30 * 1000,
+		"session:2": 2 * 3600 * 1000,
+		"session:3": -1,
+		"session:4": -1,
+		"session:5": -2,
+	}
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		if cmd != "PTTL" {
+			t.Errorf("unexpected command: %s", cmd)
+		}
+		return pttls[args[0].(string)], nil
+	}}
+	keys := []any{"session:1", "session:2", "session:3", "session:4", "session:5"}
+
+	for _, pipelined := range []bool{true, false} {
+		ttls := newHistogramAccumulator(keysTTLBuckets)
+		withoutExpiry, err := observeKeysTTL(c, keys, 2, pipelined, ttls)
+		if err != nil {
+			t.Fatalf("observeKeysTTL() err: %s", err)
+		}
+		if withoutExpiry != 2 {
+			t.Errorf("pipelined: %t, expected 2 keys without expiry, got: %d", pipelined, withoutExpiry)
+		}
+		if ttls.count != 2 || ttls.sum != 7230 {
+			t.Errorf("pipelined: %t, unexpected ttl histogram count/sum: %d / %f", pipelined, ttls.count, ttls.sum)
+		}
+		if b := ttls.buckets(); b[60] != 1 || b[3600] != 1 || b[21600] != 2 {
+			t.Errorf("pipelined: %t, unexpected ttl buckets: %v", pipelined, b)
+		}
+	}
+}
+
+func TestCountKeysInclTTL(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CountKeys: "db1=session:*", CountKeysInclTTL: true, CheckKeysBatchSize: 10})
+
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch cmd {
+		case "SELECT":
+			return "OK", nil
+		case "SCAN":
+			// the keys are fetched by SCAN batch
+			if args[0] == 0 {
+				return []any{[]byte("7"), []any{[]byte("session:1"), []byte("session:2")}}, nil
+			}
+			return []any{[]byte("0"), []any{[]byte("session:3")}}, nil
+		case "PTTL":
+			// keys returned by SCAN are passed on as []byte
+			if key, _ := redis.String(args[0], nil); key == "session:1" {
+				return int64(-1), nil
+			}
+			return int64(10 * 1000), nil
+		}
+		t.Errorf("unexpected command: %s", cmd)
+		return nil, nil
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractCountKeysMetrics(chM, c)
+		close(chM)
+	}()
+
+	found := map[string]bool{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		switch desc := m.Desc().String(); {
+		case strings.Contains(desc, "keys_count"):
+			found["count"] = d.GetGauge().GetValue() == 3
+		case strings.Contains(desc, "keys_without_expiry_count"):
+			found["without_expiry"] = d.GetGauge().GetValue() == 1
+		case strings.Contains(desc, "keys_ttl_seconds"):
+			found["ttl"] = d.GetHistogram().GetSampleCount() == 2 && d.GetHistogram().GetSampleSum() == 20
+		}
+	}
+	for _, n := range []string{"count", "without_expiry", "ttl"} {
+		if !found[n] {
+			t.Errorf("missing or wrong metric %s, found: %v", n, found)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type keyspaceEventKey struct {
+	db       string
+	event    string
+	keyGroup string
+}
+
+// keyspaceEventChannels returns the __keyevent@*__ channel patterns for the comma separated list of events
+func keyspaceEventChannels(eventsArg string) []string {
+	var channels []string
+	for ev := range strings.SplitSeq(eventsArg, ",") {
+		if ev = strings.TrimSpace(ev); ev != "" {
+			channels = append(channels, "__keyevent@*__:"+ev)
+		}
+	}
+	return channels
+}
+
+// parseKeyspaceEventChannel returns the db and the event of a channel like __keyevent@0__:expired
+func parseKeyspaceEventChannel(channel string) (db string, event string, ok bool) {
+	rest, ok := strings.CutPrefix(channel, "__keyevent@")
+	if !ok {
+		return "", "", false
+	}
+	db, event, ok = strings.Cut(rest, "__:")
+	if !ok || db == "" || event == "" {
+		return "", "", false
+	}
+	return db, event, true
+}
+
+func (e *Exporter) extractKeyspaceEventMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	channels := keyspaceEventChannels(e.options.KeyspaceEvents)
+	if len(channels) == 0 {
+		return
+	}
+
+	e.keyspaceEventsOnce.Do(func() {
+		// keyspace notifications are disabled by default, without them the counters would silently stay empty
+		if cfg, err := redis.Strings(doRedisCmd(c, e.options.ConfigCommandName, "GET", "notify-keyspace-events")); err == nil && len(cfg) == 2 && !strings.Contains(cfg[1], "E") {
+			log.Warnf("keyspace events are enabled but notify-keyspace-events is %q, keyevent notifications (E) need to be enabled on the redis instance", cfg[1])
+		}
+		go e.subscribe("keyspace events", channels, true, e.handleKeyspaceEvent)
+	})
+
+	e.keyspaceEventsMtx.Lock()
+	defer e.keyspaceEventsMtx.Unlock()
+
+	for k, cnt := range e.keyspaceEventCounters {
+		e.registerConstMetric(ch, "keyspace_events_total", cnt, prometheus.CounterValue, "db"+k.db, k.event, k.keyGroup)
+	}
+}
+
+func (e *Exporter) handleKeyspaceEvent(channel string, data []byte) {
+	db, event, ok := parseKeyspaceEventChannel(channel)
+	if !ok {
+		log.Debugf("Couldn't parse keyspace event channel: %s", channel)
+		return
+	}
+
+	k := keyspaceEventKey{db: db, event: event, keyGroup: e.keyspaceEventKeyGroup(string(data))}
+
+	e.keyspaceEventsMtx.Lock()
+	defer e.keyspaceEventsMtx.Unlock()
+
+	// same limit as for the key group metrics, automatically derived key groups could explode otherwise
+	groups := e.keyspaceEventGroups[db]
+	if groups == nil {
+		groups = map[string]bool{}
+		e.keyspaceEventGroups[db] = groups
+	}
+	if !groups[k.keyGroup] && k.keyGroup != "overflow" {
+		if e.options.MaxDistinctKeyGroups > 0 && int64(len(groups)) >= e.options.MaxDistinctKeyGroups {
+			k.keyGroup = "overflow"
+		} else {
+			groups[k.keyGroup] = true
+		}
+	}
+	e.keyspaceEventCounters[k]++
+}
+
+// keyspaceEventKeyGroup classifies the key like the key groups metrics: by the check-key-groups regexes,
+// the automatically derived key group if check-key-groups-auto-depth is set or "unclassified".
+func (e *Exporter) keyspaceEventKeyGroup(key string) string {
+	return e.keyspaceEventKeyGroups.keyGroup(key)
+}
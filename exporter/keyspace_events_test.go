@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestKeyspaceEventsParseChannel(t *testing.T) {
+	for channel, want := range map[string][]string{
+		"__keyevent@0__:expired":  {"0", "expired"},
+		"__keyevent@12__:evicted": {"12", "evicted"},
+		"__keyspace@0__:mykey":    nil,
+		"__keyevent@0__":          nil,
+	} {
+		db, event, ok := parseKeyspaceEventChannel(channel)
+		if want == nil {
+			if ok {
+				t.Errorf("channel %s: expected an error", channel)
+			}
+			continue
+		}
+		if !ok || db != want[0] || event != want[1] {
+			t.Errorf("channel %s: want %v, got %s %s %t", channel, want, db, event, ok)
+		}
+	}
+
+	if got := strings.Join(keyspaceEventChannels("expired, evicted,"), ","); got != "__keyevent@*__:expired,__keyevent@*__:evicted" {
+		t.Errorf("unexpected channels: %s", got)
+	}
+}
+
+func TestKeyspaceEventsAutoKeyGroup(t *testing.T) {
+	for key, want := range map[string]string{
+		"user:1234:profile":                         "user:<num>",
+		"sess:550e8400-e29b-41d4-a716-446655440000": "sess:<uuid>",
+		"cache:deadbeef12:x":                        "cache:<hex>",
+		"cache:deadbeef":                            "cache:deadbeef",
+		"plain":                                     "plain",
+		"a::b":                                      "a:",
+	} {
+		if got := autoKeyGroup(key, ":", 2); got != want {
+			t.Errorf("key %s: want %s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestKeyspaceEventsMetrics(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", KeyspaceEvents: "expired,evicted", CheckKeyGroups: "^(sessions):", KeyGroupsAutoDepth: 1, KeyGroupsAutoDelimiter: ":", MaxDistinctKeyGroups: 2})
+	if err != nil {
+		t.Fatalf("NewRedisExporter() err: %s", err)
+	}
+	// don't start the subscriber
+	e.keyspaceEventsOnce.Do(func() {})
+
+	e.handleKeyspaceEvent("__keyevent@0__:expired", []byte("sessions:1"))
+	e.handleKeyspaceEvent("__keyevent@0__:expired", []byte("sessions:2"))
+	e.handleKeyspaceEvent("__keyevent@0__:evicted", []byte("cache:1"))
+	e.handleKeyspaceEvent("__keyevent@0__:evicted", []byte("queue:1"))
+	e.handleKeyspaceEvent("__keyevent@0__:evicted", []byte("jobs:1"))
+	e.handleKeyspaceEvent("__keyevent@1__:evicted", []byte("queue:1"))
+	e.handleKeyspaceEvent("invalid", []byte("queue:1"))
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractKeyspaceEventMetrics(chM, nil)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		lbls := map[string]string{}
+		for _, l := range d.GetLabel() {
+			lbls[l.GetName()] = l.GetValue()
+		}
+		got[lbls["db"]+"/"+lbls["event"]+"/"+lbls["key_group"]] = d.GetCounter().GetValue()
+	}
+
+	want := map[string]float64{
+		"db0/expired/sessions": 2,
+		"db0/evicted/cache":    1,
+		"db0/evicted/overflow": 2,
+		"db1/evicted/queue":    1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: want %f, got %f", k, v, got[k])
+		}
+	}
+
+	// the key groups over the limit aren't admitted, so they don't grow the set that's checked by every event
+	if groups := e.keyspaceEventGroups["0"]; len(groups) != 2 || !groups["sessions"] || !groups["cache"] {
+		t.Errorf("unexpected admitted key groups of db0: %v", groups)
+	}
+
+	if _, err := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", KeyspaceEvents: "expired", CheckKeyGroups: "[a-z"}); err == nil {
+		t.Errorf("expected an error for an invalid key group regex")
+	}
+}
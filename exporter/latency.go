@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -17,6 +18,16 @@ var (
 	extractUsecRegexp = regexp.MustCompile(`(?m)^cmdstat_([a-zA-Z0-9\|]+):.*usec=([0-9]+).*$`)
 )
 
+// buckets of latency_history_spike_duration_seconds, the latency monitor works with millisecond resolution
+var latencyHistoryBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// latencyHistoryEvent holds the spikes of an event of the latency monitor that were ingested from LATENCY HISTORY
+type latencyHistoryEvent struct {
+	// timestamp of the latest ingested sample
+	watermark int64
+	spikes    *histogramAccumulator
+}
+
 func (e *Exporter) extractLatencyMetrics(ch chan<- prometheus.Metric, infoAll string, c redis.Conn) {
 	e.extractLatencyLatestMetrics(ch, c)
 	e.extractLatencyHistogramMetrics(ch, infoAll, c)
@@ -49,6 +60,64 @@ func (e *Exporter) extractLatencyLatestMetrics(outChan chan<- prometheus.Metric,
 	}
 }
 
+/*
+https://redis.io/docs/latest/commands/latency-history/
+
+LATENCY HISTORY returns up to 160 [timestamp, latency in ms] samples per event, samples newer
+than the watermark of the event are added to the spike histogram so every sample is counted once.
+The events are the ones reported by LATENCY LATEST.
+*/
+func (e *Exporter) extractLatencyHistoryMetrics(outChan chan<- prometheus.Metric, redisConn redis.Conn) {
+	latest, err := redis.Values(doRedisCmd(redisConn, "LATENCY", "LATEST"))
+	if err != nil {
+		log.Debugf("cmd LATENCY LATEST, err: %s", err)
+	}
+
+	for _, l := range latest {
+		var eventName string
+		if _, err := redis.Scan(mustValues(l), &eventName); err != nil || eventName == "" {
+			continue
+		}
+
+		reply, err := redis.Values(doRedisCmd(redisConn, "LATENCY", "HISTORY", eventName))
+		if err != nil {
+			log.Debugf("cmd LATENCY HISTORY %s, err: %s", eventName, err)
+			continue
+		}
+
+		ev := e.latencyHistory[eventName]
+		if ev == nil {
+			ev = &latencyHistoryEvent{spikes: newHistogramAccumulator(latencyHistoryBuckets)}
+			e.latencyHistory[eventName] = ev
+		}
+
+		watermark := ev.watermark
+		for _, sample := range reply {
+			var timestamp, latencyMs int64
+			if _, err := redis.Scan(mustValues(sample), &timestamp, &latencyMs); err != nil {
+				log.Debugf("couldn't parse LATENCY HISTORY %s sample: %v, err: %s", eventName, sample, err)
+				continue
+			}
+			if timestamp <= ev.watermark {
+				continue
+			}
+			ev.spikes.observe(float64(latencyMs) / 1e3)
+			watermark = max(watermark, timestamp)
+		}
+		ev.watermark = watermark
+	}
+
+	// events stay exported after LATENCY RESET, so the histograms don't reset
+	for eventName, ev := range e.latencyHistory {
+		e.registerConstHistogram(outChan, "latency_history_spike_duration_seconds", ev.spikes.count, ev.spikes.sum, ev.spikes.buckets(), eventName)
+	}
+}
+
+func mustValues(reply any) []any {
+	values, _ := redis.Values(reply, nil)
+	return values
+}
+
 /*
 https://redis.io/docs/latest/commands/latency-histogram/
 */
@@ -62,6 +131,12 @@ func (e *Exporter) extractLatencyHistogramMetrics(outChan chan<- prometheus.Metr
 		return
 	}
 
+	// the slowest call per command of the slowlog is attached to its histogram as exemplar
+	var exemplars map[string]prometheus.Exemplar
+	if e.options.InclSlowlogExemplars {
+		exemplars = e.getSlowlogExemplars(redisConn)
+	}
+
 	for i := 0; i+1 < len(reply); i += 2 {
 		cmd, _ := redis.String(reply[i], nil)
 		details, _ := redis.Values(reply[i+1], nil)
@@ -84,8 +159,45 @@ func (e *Exporter) extractLatencyHistogramMetrics(outChan chan<- prometheus.Metr
 		totalUsecs := extractTotalUsecForCommand(infoAll, cmd)
 
 		e.createMetricDescription("commands_latencies_usec", []string{"cmd"})
-		e.registerConstHistogram(outChan, "commands_latencies_usec", totalCalls, float64(totalUsecs), buckets, cmd)
+		var histogram prometheus.Metric
+		if e.options.InclNativeLatencyHistograms {
+			histogram = e.newConstNativeHistogram("commands_latencies_usec", totalCalls, float64(totalUsecs), buckets, 0, latencyNativeHistogramBuckets(bucketInfo), cmd)
+		} else {
+			histogram = e.newConstHistogram("commands_latencies_usec", totalCalls, float64(totalUsecs), buckets, cmd)
+		}
+		if exemplar, ok := exemplars[cmd]; ok {
+			if withExemplar, err := prometheus.NewMetricWithExemplars(histogram, exemplar); err == nil {
+				histogram = withExemplar
+			} else {
+				log.Debugf("commands_latencies_usec: couldn't attach the slowlog exemplar of %s, err: %s", cmd, err)
+			}
+		}
+		outChan <- histogram
+	}
+}
+
+/*
+latencyNativeHistogramBuckets converts the cumulative LATENCY HISTOGRAM buckets to the buckets of a schema 0 native
+histogram, whose bucket i holds the observations in (2^(i-1), 2^i]. Redis records the latencies in nanoseconds and reports
+the power-of-two buckets starting at 1024ns in microseconds (1, 2, 4, ..., 32, 65, 131, ...), they're mapped to the
+nearest power of two in microseconds.
+*/
+func latencyNativeHistogramBuckets(bucketInfo []uint64) map[int]int64 {
+	nativeBuckets := map[int]int64{}
+	var prevCount uint64
+	for j := 0; j+1 < len(bucketInfo); j += 2 {
+		usec, count := bucketInfo[j], bucketInfo[j+1]
+		if count <= prevCount {
+			continue
+		}
+		idx := 0
+		if usec > 1 {
+			idx = int(math.Round(math.Log2(float64(usec))))
+		}
+		nativeBuckets[idx] += int64(count - prevCount)
+		prevCount = count
 	}
+	return nativeBuckets
 }
 
 func extractTotalUsecForCommand(infoAll string, cmd string) uint64 {
@@ -163,3 +163,183 @@ func TestLatencyStats(t *testing.T) {
 	commandStatsCheck(t, e, want)
 	deleteTestKeys(t, redisSevenAddr)
 }
+
+func TestLatencyHistoryWatermark(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclLatencyHistory: true})
+
+	history := map[string][]any{
+		"fork":    {[]any{int64(100), int64(5)}, []any{int64(110), int64(250)}},
+		"command": {[]any{int64(105), int64(12)}},
+	}
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch {
+		case cmd == "LATENCY" && args[0] == "LATEST":
+			return []any{[]any{[]byte("fork"), int64(110), int64(250), int64(250)}, []any{[]byte("command"), int64(105), int64(12), int64(12)}}, nil
+		case cmd == "LATENCY" && args[0] == "HISTORY":
+			return history[args[1].(string)], nil
+		}
+		t.Errorf("unexpected command: %s %v", cmd, args)
+		return nil, nil
+	}}
+
+	collect := func() map[string]*dto.Histogram {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractLatencyHistoryMetrics(chM, c)
+			close(chM)
+		}()
+		got := map[string]*dto.Histogram{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			got[d.GetLabel()[0].GetValue()] = d.GetHistogram()
+		}
+		return got
+	}
+
+	got := collect()
+	if h := got["fork"]; h.GetSampleCount() != 2 || math.Abs(h.GetSampleSum()-0.255) > 1e-9 {
+		t.Errorf("unexpected fork histogram: %v", h)
+	}
+	if got["command"].GetSampleCount() != 1 {
+		t.Errorf("unexpected command spikes: %v", got["command"])
+	}
+
+	// samples up to the watermark are only counted once, newer ones are added
+	history["fork"] = append(history["fork"], []any{int64(120), int64(40)})
+	history["command"] = nil
+	got = collect()
+	if got["fork"].GetSampleCount() != 3 {
+		t.Errorf("expected 3 fork spikes, got: %v", got["fork"])
+	}
+	if got["command"].GetSampleCount() != 1 {
+		t.Errorf("expected the command spikes to survive LATENCY RESET, got: %v", got["command"])
+	}
+	for _, b := range got["fork"].GetBucket() {
+		if b.GetUpperBound() == 0.05 && b.GetCumulativeCount() != 2 {
+			t.Errorf("unexpected count of the 50ms bucket: %d", b.GetCumulativeCount())
+		}
+	}
+}
+
+func TestNativeLatencyHistogram(t *testing.T) {
+	infoAll := "# Commandstats\ncmdstat_get:calls=10,usec=150,usec_per_call=15.00\n"
+	histogram := []any{
+		"get", []any{"calls", int64(10), "histogram_usec", []any{int64(1), int64(2), int64(16), int64(7), int64(65), int64(10)}},
+	}
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		return histogram, nil
+	}}
+
+	for _, native := range []bool{false, true} {
+		e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclNativeLatencyHistograms: native})
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractLatencyHistogramMetrics(chM, infoAll, c)
+			close(chM)
+		}()
+
+		var metrics []*dto.Metric
+		for m := range chM {
+			d := &dto.Metric{}
+			if err := m.Write(d); err != nil {
+				t.Fatalf("Write() err: %s", err)
+			}
+			metrics = append(metrics, d)
+		}
+		if len(metrics) != 1 {
+			t.Fatalf("expected 1 histogram, got: %v", metrics)
+		}
+
+		h := metrics[0].GetHistogram()
+		if h.GetSampleCount() != 10 || h.GetSampleSum() != 150 || len(h.GetBucket()) != 3 {
+			t.Errorf("unexpected classic histogram: %v", h)
+		}
+		if !native {
+			if h.Schema != nil || len(h.GetPositiveSpan()) != 0 {
+				t.Errorf("expected no native histogram, got: %v", h)
+			}
+			continue
+		}
+
+		// 1us -> bucket 0, 16us -> bucket 4 and 65us -> bucket 6 (64us), encoded as spans and deltas
+		if h.GetSchema() != 0 || h.GetZeroCount() != 0 {
+			t.Errorf("unexpected native histogram: %v", h)
+		}
+		spans := h.GetPositiveSpan()
+		if len(spans) != 2 || spans[0].GetOffset() != 0 || spans[0].GetLength() != 1 || spans[1].GetOffset() != 3 || spans[1].GetLength() != 3 {
+			t.Errorf("unexpected native spans: %v", spans)
+		}
+		if deltas := fmt.Sprint(h.GetPositiveDelta()); deltas != "[2 3 -5 3]" {
+			t.Errorf("unexpected native deltas: %s", deltas)
+		}
+	}
+}
+
+func TestLatencyNativeHistogramBuckets(t *testing.T) {
+	got := latencyNativeHistogramBuckets([]uint64{1, 5, 2, 5, 32, 6, 131, 8, 1048, 9})
+	want := map[int]int64{0: 5, 5: 1, 7: 2, 10: 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLatencyHistogramSlowlogExemplars(t *testing.T) {
+	histogram := []any{
+		"get", []any{"calls", int64(10), "histogram_usec", []any{int64(1), int64(2), int64(16), int64(7), int64(65), int64(10)}},
+		"config|get", []any{"calls", int64(1), "histogram_usec", []any{int64(2048), int64(1)}},
+		"set", []any{"calls", int64(1), "histogram_usec", []any{int64(1), int64(1)}},
+	}
+	slowlog := []any{
+		[]any{int64(12), int64(1700000100), int64(40), []any{[]byte("GET"), []byte("user:1")}, []byte("10.0.0.1:5000"), []byte("worker")},
+		[]any{int64(11), int64(1700000050), int64(2000), []any{[]byte("CONFIG"), []byte("GET"), []byte("*")}, []byte("10.0.0.2:5000"), []byte("")},
+		[]any{int64(10), int64(1700000000), int64(30), []any{[]byte("get"), []byte("user:2")}, []byte("10.0.0.3:5000"), []byte("")},
+	}
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		if cmd == "SLOWLOG" {
+			return slowlog, nil
+		}
+		return histogram, nil
+	}}
+
+	for _, native := range []bool{false, true} {
+		e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclSlowlogExemplars: true, InclNativeLatencyHistograms: native})
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractLatencyHistogramMetrics(chM, "", c)
+			close(chM)
+		}()
+
+		exemplars := map[string]*dto.Exemplar{}
+		for m := range chM {
+			d := &dto.Metric{}
+			if err := m.Write(d); err != nil {
+				t.Fatalf("Write() err: %s", err)
+			}
+			cmd := d.GetLabel()[0].GetValue()
+			for _, b := range d.GetHistogram().GetBucket() {
+				if b.Exemplar != nil {
+					exemplars[cmd] = b.Exemplar
+				}
+			}
+			if native && (len(d.GetHistogram().GetExemplars()) == 1) != (exemplars[cmd] != nil) {
+				t.Errorf("expected the exemplar in the native histogram of %s as well, got: %v", cmd, d.GetHistogram())
+			}
+		}
+
+		if len(exemplars) != 2 || exemplars["set"] != nil {
+			t.Fatalf("expected exemplars for get and config|get, got: %v", exemplars)
+		}
+		labels := map[string]string{}
+		for _, l := range exemplars["get"].GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if exemplars["get"].GetValue() != 40 || exemplars["get"].GetTimestamp().GetSeconds() != 1700000100 ||
+			labels["slowlog_id"] != "12" || labels["client_addr"] != "10.0.0.1:5000" || labels["client_name"] != "worker" || labels["cmd"] != "get" || labels["args_hash"] == "" {
+			t.Errorf("unexpected exemplar of get: %v", exemplars["get"])
+		}
+		if exemplars["config|get"].GetValue() != 2000 {
+			t.Errorf("unexpected exemplar of config|get: %v", exemplars["config|get"])
+		}
+	}
+}
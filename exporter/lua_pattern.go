@@ -0,0 +1,373 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/*
+This is a port of the pattern matching of the lua string library (lstrlib.c of lua 5.1, the version
+embedded in redis), so the key groups regexes can be applied to keys without a redis instance with
+exactly the semantics of string.find in the key groups lua script.
+*/
+
+const (
+	luaMaxCaptures = 32
+
+	luaCapUnfinished = -1
+	luaCapPosition   = -2
+)
+
+type luaPatternError string
+
+func (e luaPatternError) Error() string {
+	return string(e)
+}
+
+type luaMatchState struct {
+	src     string
+	pat     string
+	level   int
+	capture [luaMaxCaptures]struct {
+		init int
+		len  int
+	}
+}
+
+/*
+luaPatternFind is the equivalent of string.find(s, pattern), it returns the captures of the first match
+(the position captures as 1-based positions), matched is false if the pattern doesn't match s
+*/
+func luaPatternFind(s string, pattern string) (captures []string, matched bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			patternErr, ok := r.(luaPatternError)
+			if !ok {
+				panic(r)
+			}
+			err = patternErr
+		}
+	}()
+
+	ms := &luaMatchState{src: s, pat: pattern}
+	p := 0
+	anchor := len(pattern) > 0 && pattern[0] == '^'
+	if anchor {
+		p = 1
+	}
+	for s1 := 0; s1 <= len(s); s1++ {
+		ms.level = 0
+		if e := ms.match(s1, p); e != -1 {
+			captures = make([]string, ms.level)
+			for i := range ms.level {
+				captures[i] = ms.getCapture(i)
+			}
+			return captures, true, nil
+		}
+		if anchor {
+			break
+		}
+	}
+	return nil, false, nil
+}
+
+func (ms *luaMatchState) getCapture(i int) string {
+	c := ms.capture[i]
+	switch c.len {
+	case luaCapUnfinished:
+		panic(luaPatternError("unfinished capture"))
+	case luaCapPosition:
+		return strconv.Itoa(c.init + 1)
+	}
+	return ms.src[c.init : c.init+c.len]
+}
+
+// classEnd returns the index after the single char class starting at p
+func (ms *luaMatchState) classEnd(p int) int {
+	c := ms.pat[p]
+	p++
+	switch c {
+	case '%':
+		if p >= len(ms.pat) {
+			panic(luaPatternError("malformed pattern (ends with '%')"))
+		}
+		return p + 1
+	case '[':
+		if p < len(ms.pat) && ms.pat[p] == '^' {
+			p++
+		}
+		// the first char of the set is never the closing ']'
+		for {
+			if p >= len(ms.pat) {
+				panic(luaPatternError("malformed pattern (missing ']')"))
+			}
+			c := ms.pat[p]
+			p++
+			if c == '%' && p < len(ms.pat) {
+				p++
+			}
+			if p < len(ms.pat) && ms.pat[p] == ']' {
+				return p + 1
+			}
+		}
+	}
+	return p
+}
+
+func luaMatchClass(c byte, class byte) bool {
+	var res bool
+	lower := class | 0x20
+	switch lower {
+	case 'a':
+		res = isAlpha(c)
+	case 'c':
+		res = c < 32 || c == 127
+	case 'd':
+		res = c >= '0' && c <= '9'
+	case 'l':
+		res = c >= 'a' && c <= 'z'
+	case 'p':
+		res = c > 32 && c < 127 && !isAlpha(c) && !(c >= '0' && c <= '9')
+	case 's':
+		res = c == ' ' || (c >= '\t' && c <= '\r')
+	case 'u':
+		res = c >= 'A' && c <= 'Z'
+	case 'w':
+		res = isAlpha(c) || (c >= '0' && c <= '9')
+	case 'x':
+		res = (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'f')
+	default:
+		return class == c
+	}
+	if class >= 'A' && class <= 'Z' {
+		return !res
+	}
+	return res
+}
+
+func isAlpha(c byte) bool {
+	return c|0x20 >= 'a' && c|0x20 <= 'z'
+}
+
+// matchBracketClass matches c against the set starting with '[' at p and ending with ']' at ec
+func (ms *luaMatchState) matchBracketClass(c byte, p int, ec int) bool {
+	sig := true
+	if ms.pat[p+1] == '^' {
+		sig = false
+		p++
+	}
+	for p++; p < ec; p++ {
+		switch {
+		case ms.pat[p] == '%':
+			p++
+			if luaMatchClass(c, ms.pat[p]) {
+				return sig
+			}
+		case ms.pat[p+1] == '-' && p+2 < ec:
+			p += 2
+			if ms.pat[p-2] <= c && c <= ms.pat[p] {
+				return sig
+			}
+		case ms.pat[p] == c:
+			return sig
+		}
+	}
+	return !sig
+}
+
+func (ms *luaMatchState) singleMatch(s int, p int, ep int) bool {
+	if s >= len(ms.src) {
+		return false
+	}
+	c := ms.src[s]
+	switch ms.pat[p] {
+	case '.':
+		return true
+	case '%':
+		return luaMatchClass(c, ms.pat[p+1])
+	case '[':
+		return ms.matchBracketClass(c, p, ep-1)
+	}
+	return ms.pat[p] == c
+}
+
+// match returns the end of the match of the pattern from p on at s or -1
+func (ms *luaMatchState) match(s int, p int) int {
+	for p < len(ms.pat) {
+		switch ms.pat[p] {
+		case '(':
+			if p+1 < len(ms.pat) && ms.pat[p+1] == ')' {
+				return ms.startCapture(s, p+2, luaCapPosition)
+			}
+			return ms.startCapture(s, p+1, luaCapUnfinished)
+		case ')':
+			return ms.endCapture(s, p+1)
+		case '$':
+			if p+1 == len(ms.pat) {
+				if s == len(ms.src) {
+					return s
+				}
+				return -1
+			}
+		case '%':
+			if p+1 < len(ms.pat) {
+				switch ms.pat[p+1] {
+				case 'b':
+					if s = ms.matchBalance(s, p+2); s == -1 {
+						return -1
+					}
+					p += 4
+					continue
+				case 'f':
+					p += 2
+					if p >= len(ms.pat) || ms.pat[p] != '[' {
+						panic(luaPatternError("missing '[' after '%f' in pattern"))
+					}
+					ep := ms.classEnd(p)
+					var previous, current byte
+					if s > 0 {
+						previous = ms.src[s-1]
+					}
+					if s < len(ms.src) {
+						current = ms.src[s]
+					}
+					if ms.matchBracketClass(previous, p, ep-1) || !ms.matchBracketClass(current, p, ep-1) {
+						return -1
+					}
+					p = ep
+					continue
+				case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+					if s = ms.matchCapture(s, ms.pat[p+1]); s == -1 {
+						return -1
+					}
+					p += 2
+					continue
+				}
+			}
+		}
+
+		ep := ms.classEnd(p)
+		var epChar byte
+		if ep < len(ms.pat) {
+			epChar = ms.pat[ep]
+		}
+		if !ms.singleMatch(s, p, ep) {
+			if epChar == '*' || epChar == '?' || epChar == '-' {
+				// accepts an empty match
+				p = ep + 1
+				continue
+			}
+			return -1
+		}
+		switch epChar {
+		case '?':
+			if res := ms.match(s+1, ep+1); res != -1 {
+				return res
+			}
+			p = ep + 1
+		case '+':
+			return ms.maxExpand(s+1, p, ep)
+		case '*':
+			return ms.maxExpand(s, p, ep)
+		case '-':
+			return ms.minExpand(s, p, ep)
+		default:
+			s++
+			p = ep
+		}
+	}
+	return s
+}
+
+func (ms *luaMatchState) maxExpand(s int, p int, ep int) int {
+	i := 0
+	for ms.singleMatch(s+i, p, ep) {
+		i++
+	}
+	// tries with the maximum repetitions and backs off one at a time
+	for ; i >= 0; i-- {
+		if res := ms.match(s+i, ep+1); res != -1 {
+			return res
+		}
+	}
+	return -1
+}
+
+func (ms *luaMatchState) minExpand(s int, p int, ep int) int {
+	for {
+		if res := ms.match(s, ep+1); res != -1 {
+			return res
+		}
+		if !ms.singleMatch(s, p, ep) {
+			return -1
+		}
+		s++
+	}
+}
+
+func (ms *luaMatchState) startCapture(s int, p int, what int) int {
+	if ms.level >= luaMaxCaptures {
+		panic(luaPatternError("too many captures"))
+	}
+	ms.capture[ms.level].init = s
+	ms.capture[ms.level].len = what
+	ms.level++
+	res := ms.match(s, p)
+	if res == -1 {
+		ms.level--
+	}
+	return res
+}
+
+func (ms *luaMatchState) endCapture(s int, p int) int {
+	l := -1
+	for i := ms.level - 1; i >= 0; i-- {
+		if ms.capture[i].len == luaCapUnfinished {
+			l = i
+			break
+		}
+	}
+	if l == -1 {
+		panic(luaPatternError("invalid pattern capture"))
+	}
+	ms.capture[l].len = s - ms.capture[l].init
+	res := ms.match(s, p)
+	if res == -1 {
+		ms.capture[l].len = luaCapUnfinished
+	}
+	return res
+}
+
+func (ms *luaMatchState) matchBalance(s int, p int) int {
+	if p+1 >= len(ms.pat) {
+		panic(luaPatternError("unbalanced pattern"))
+	}
+	if s >= len(ms.src) || ms.src[s] != ms.pat[p] {
+		return -1
+	}
+	b, e := ms.pat[p], ms.pat[p+1]
+	cont := 1
+	for s++; s < len(ms.src); s++ {
+		switch ms.src[s] {
+		case e:
+			if cont--; cont == 0 {
+				return s + 1
+			}
+		case b:
+			cont++
+		}
+	}
+	return -1
+}
+
+func (ms *luaMatchState) matchCapture(s int, l byte) int {
+	i := int(l) - '1'
+	if i < 0 || i >= ms.level || ms.capture[i].len == luaCapUnfinished {
+		panic(luaPatternError(fmt.Sprintf("invalid capture index %%%d", i+1)))
+	}
+	c := ms.capture[i]
+	if len(ms.src)-s >= c.len && ms.src[c.init:c.init+c.len] == ms.src[s:s+c.len] {
+		return s + c.len
+	}
+	return -1
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLuaPatternFind(t *testing.T) {
+	for _, tst := range []struct {
+		s        string
+		pattern  string
+		matched  bool
+		captures string
+	}{
+		{s: "user:123:profile", pattern: "^(user):%d+", matched: true, captures: "user"},
+		{s: "user:123:profile", pattern: "^(%a+):(%d+)", matched: true, captures: "user,123"},
+		{s: "session:abc", pattern: "^(user):", matched: false},
+		{s: "cache:de:item", pattern: "cache:(%l%l):", matched: true, captures: "de"},
+		{s: "cache:DE:item", pattern: "cache:(%u+):", matched: true, captures: "DE"},
+		{s: "a.b.c", pattern: "^(.-)%.", matched: true, captures: "a"},
+		{s: "a.b.c", pattern: "^(.*)%.", matched: true, captures: "a.b"},
+		{s: "key-42", pattern: "^(key)%-?()", matched: true, captures: "key,5"},
+		{s: "k[x]y", pattern: "(%b[])", matched: true, captures: "[x]"},
+		{s: "order_1", pattern: "^([%w_]+)$", matched: true, captures: "order_1"},
+		{s: "order 1", pattern: "^([^%s]+)$", matched: false},
+		{s: "THE (quick) fox", pattern: "%f[%a](%a+)%f[%A]", matched: true, captures: "THE"},
+		{s: "abab", pattern: "^(ab)%1$", matched: true, captures: "ab"},
+		{s: "no captures", pattern: "cap", matched: true, captures: ""},
+		{s: "anything", pattern: "", matched: true, captures: ""},
+		{s: "a]b", pattern: "([]])", matched: true, captures: "]"},
+	} {
+		captures, matched, err := luaPatternFind(tst.s, tst.pattern)
+		if err != nil {
+			t.Errorf("luaPatternFind(%q, %q) err: %s", tst.s, tst.pattern, err)
+			continue
+		}
+		if matched != tst.matched || strings.Join(captures, ",") != tst.captures {
+			t.Errorf("luaPatternFind(%q, %q) = %q, %t, expected %q, %t", tst.s, tst.pattern, captures, matched, tst.captures, tst.matched)
+		}
+	}
+
+	for _, pattern := range []string{"(abc", "abc)", "[a-z", "abc%", "%1", "%f"} {
+		if _, _, err := luaPatternFind("abc", pattern); err == nil {
+			t.Errorf("expected an error for pattern %q", pattern)
+		}
+	}
+}
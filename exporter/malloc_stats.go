@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// Allocated: 1145608, active: 1351680, metadata: 2766048 (n_thp 0), resident: 4014080, mapped: 7340032, retained: 1114112
+	mallocStatsTotalsRE = regexp.MustCompile(`(?i)(allocated|active|metadata|resident|mapped|retained): (\d+)`)
+	mallocStatsArenasRE = regexp.MustCompile(`^Arenas: (\d+)`)
+)
+
+// mallocStatsBin is a row of the "bins:" table of the merged arenas stats of jemalloc
+type mallocStatsBin struct {
+	size      string
+	allocated float64
+	curregs   float64
+	curslabs  float64
+	regs      float64
+}
+
+type mallocStats struct {
+	totals     map[string]float64
+	arenas     float64
+	dirtyPages float64
+	muzzyPages float64
+	bins       []mallocStatsBin
+}
+
+func (e *Exporter) extractMallocStatsMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	info, err := redis.String(doRedisCmd(c, "MEMORY", "MALLOC-STATS"))
+	if err != nil {
+		log.Errorf("extractMallocStatsMetrics() err: %s", err)
+		return
+	}
+	if !strings.Contains(info, "jemalloc statistics") {
+		log.Debugf("extractMallocStatsMetrics() allocator doesn't provide jemalloc statistics: %s", info)
+		return
+	}
+
+	stats := parseMallocStats(info)
+
+	for name, val := range stats.totals {
+		e.registerConstMetricGauge(ch, "malloc_stats_"+name+"_bytes", val)
+	}
+	e.registerConstMetricGauge(ch, "malloc_stats_arenas", stats.arenas)
+	e.registerConstMetricGauge(ch, "malloc_stats_dirty_pages", stats.dirtyPages)
+	e.registerConstMetricGauge(ch, "malloc_stats_muzzy_pages", stats.muzzyPages)
+
+	for _, bin := range stats.bins {
+		size, err := strconv.ParseFloat(bin.size, 64)
+		if err != nil {
+			continue
+		}
+		capacity := bin.curslabs * bin.regs
+		utilization := 0.0
+		if capacity > 0 {
+			utilization = bin.curregs / capacity
+		}
+		e.registerConstMetricGauge(ch, "malloc_stats_bin_allocated_bytes", bin.allocated, bin.size)
+		e.registerConstMetricGauge(ch, "malloc_stats_bin_regions", bin.curregs, bin.size)
+		e.registerConstMetricGauge(ch, "malloc_stats_bin_slabs", bin.curslabs, bin.size)
+		e.registerConstMetricGauge(ch, "malloc_stats_bin_utilization_ratio", utilization, bin.size)
+		e.registerConstMetricGauge(ch, "malloc_stats_bin_fragmented_bytes", (capacity-bin.curregs)*size, bin.size)
+	}
+}
+
+// parseMallocStats parses the output of jemalloc's malloc_stats_print(), only the first "bins:" table
+// (the one of the merged arenas) is used. The columns of the table differ between jemalloc versions
+// so they are looked up by the names in the header.
+func parseMallocStats(info string) mallocStats {
+	stats := mallocStats{totals: map[string]float64{}}
+
+	var binColumns map[string]int
+	var binColumnsCount int
+	inBins, binsDone, decayingDone := false, false, false
+	for line := range strings.SplitSeq(info, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+
+		switch {
+		case mallocStatsArenasRE.MatchString(line):
+			stats.arenas, _ = strconv.ParseFloat(mallocStatsArenasRE.FindStringSubmatch(line)[1], 64)
+
+		case strings.HasPrefix(line, "Allocated:") && len(stats.totals) == 0:
+			for _, m := range mallocStatsTotalsRE.FindAllStringSubmatch(line, -1) {
+				stats.totals[strings.ToLower(m[1])], _ = strconv.ParseFloat(m[2], 64)
+			}
+
+		// decaying:  time       npages       sweeps     madvises       purged
+		//    dirty:   N/A           34           0            0            0
+		case !decayingDone && len(fields) > 2 && (fields[0] == "dirty:" || fields[0] == "muzzy:"):
+			pages, _ := strconv.ParseFloat(fields[2], 64)
+			if fields[0] == "dirty:" {
+				stats.dirtyPages = pages
+			} else {
+				stats.muzzyPages = pages
+				decayingDone = true
+			}
+
+		case !binsDone && strings.HasPrefix(line, "bins:"):
+			binColumns, binColumnsCount = mallocStatsColumns(fields[1:]), len(fields)-1
+			inBins = true
+
+		case inBins:
+			if line == "---" {
+				continue
+			}
+			if len(fields) != binColumnsCount {
+				// end of the bins table
+				inBins, binsDone = false, true
+				continue
+			}
+			value := func(column string) float64 {
+				idx, ok := binColumns[column]
+				if !ok {
+					return 0
+				}
+				v, _ := strconv.ParseFloat(fields[idx], 64)
+				return v
+			}
+			stats.bins = append(stats.bins, mallocStatsBin{
+				size:      fields[binColumns["size"]],
+				allocated: value("allocated"),
+				curregs:   value("curregs"),
+				curslabs:  value("curslabs"),
+				regs:      value("regs"),
+			})
+		}
+	}
+	return stats
+}
+
+// mallocStatsColumns maps the column names of a table header to their index,
+// the "(#/sec)" rate columns only take up an index.
+func mallocStatsColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	return columns
+}
@@ -0,0 +1,168 @@
+package exporter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// abbreviated output of MEMORY MALLOC-STATS of redis 7.2 with jemalloc 5.3.0
+const testMallocStats = `___ Begin jemalloc statistics ___
+Version: "5.3.0-0-g0"
+Build-time option settings
+  config.cache_oblivious: true
+Run-time option settings
+  opt.abort: false
+Profiling settings
+  prof.thread_active_init: true
+Arenas: 4
+Quantum size: 8
+Page size: 4096
+Maximum thread-cached size class: 32768
+Number of bin size classes: 36
+Number of thread-cache bin size classes: 41
+Number of large size classes: 196
+Allocated: 1145608, active: 1351680, metadata: 2766048 (n_thp 0), resident: 4014080, mapped: 7340032, retained: 1114112
+Count of realloc(non-null-ptr, 0) calls: 0
+Background threads: 0, num_runs: 0, run_interval: 0 ns
+Merged arenas stats:
+assigned threads: 1
+uptime: 2530994893
+dss allocation precedence: "secondary"
+decaying:  time       npages       sweeps     madvises       purged
+   dirty:   N/A           34            0            0            0
+   muzzy:   N/A            5            0            0            0
+                            allocated     nmalloc (#/sec)     ndalloc (#/sec)   nrequests   (#/sec)  nfill (#/sec)  nflush (#/sec)
+small:                         817160        3346       1        1008       0        6203       2       53      0       12      0
+large:                         328448           9       0           2       0           9       0        9      0        0      0
+total:                        1145608        3355       1        1010       0        6212       2       62      0       12      0
+bins:           size ind    allocated      nmalloc (#/sec)      ndalloc (#/sec)    nrequests   (#/sec)  nshards      curregs     curslabs  nonfull_slabs regs pgs   util       nfills (#/sec)     nflushes (#/sec)       nslabs     nreslabs (#/sec)      n_lock_ops (#/sec)       n_waiting (#/sec)      n_spin_acq (#/sec)  n_owner_switch (#/sec)   total_wait_ns   (#/sec)     max_wait_ns  max_n_thds
+                   8   0         3200  0  0  0  0  0  0  1  400  1  0  512  1  0.781  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0
+                  16   1         1600  0  0  0  0  0  0  1  100  2  0  256  1  0.195  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0
+                     ---
+                4096  10        12288  0  0  0  0  0  0  1  3  1  0  4  4  0.75  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0
+large:          size ind    allocated      nmalloc (#/sec)      ndalloc (#/sec)    nrequests (#/sec)  curlextents
+               16384  36        16384            1       0           0       0           1       0            1
+arenas[0]:
+assigned threads: 1
+decaying:  time       npages       sweeps     madvises       purged
+   dirty:   N/A           99            0            0            0
+   muzzy:   N/A           99            0            0            0
+bins:           size ind    allocated      nmalloc (#/sec)      ndalloc (#/sec)    nrequests   (#/sec)  nshards      curregs     curslabs  nonfull_slabs regs pgs   util       nfills (#/sec)     nflushes (#/sec)       nslabs     nreslabs (#/sec)      n_lock_ops (#/sec)       n_waiting (#/sec)      n_spin_acq (#/sec)  n_owner_switch (#/sec)   total_wait_ns   (#/sec)     max_wait_ns  max_n_thds
+                   8   0         9999  0  0  0  0  0  0  1  9999  1  0  512  1  0.781  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0  0
+--- End jemalloc statistics ---
+`
+
+func TestMallocStatsParse(t *testing.T) {
+	stats := parseMallocStats(testMallocStats)
+
+	if stats.arenas != 4 || stats.dirtyPages != 34 || stats.muzzyPages != 5 {
+		t.Errorf("unexpected arenas/dirty/muzzy: %f %f %f", stats.arenas, stats.dirtyPages, stats.muzzyPages)
+	}
+	for name, want := range map[string]float64{"allocated": 1145608, "active": 1351680, "metadata": 2766048, "resident": 4014080, "mapped": 7340032, "retained": 1114112} {
+		if stats.totals[name] != want {
+			t.Errorf("total %s: want %f, got %f", name, want, stats.totals[name])
+		}
+	}
+
+	// only the bins of the merged arenas
+	if len(stats.bins) != 3 {
+		t.Fatalf("expected 3 bins, got: %#v", stats.bins)
+	}
+	if b := stats.bins[1]; b.size != "16" || b.allocated != 1600 || b.curregs != 100 || b.curslabs != 2 || b.regs != 256 {
+		t.Errorf("unexpected bin: %#v", b)
+	}
+}
+
+func TestMallocStatsMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclMallocStats: true})
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		return []byte(testMallocStats), nil
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractMallocStatsMetrics(chM, c)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		if strings.Contains(desc, `help: "`+strings.TrimPrefix(name, "test_")+` metric"`) {
+			t.Errorf("no description for %s", name)
+		}
+		for _, l := range d.GetLabel() {
+			name += "/" + l.GetValue()
+		}
+		got[name] = d.GetGauge().GetValue()
+	}
+
+	for name, want := range map[string]float64{
+		"test_malloc_stats_resident_bytes":             4014080,
+		"test_malloc_stats_arenas":                     4,
+		"test_malloc_stats_dirty_pages":                34,
+		"test_malloc_stats_bin_regions/16":             100,
+		"test_malloc_stats_bin_slabs/16":               2,
+		"test_malloc_stats_bin_utilization_ratio/16":   100.0 / 512,
+		"test_malloc_stats_bin_fragmented_bytes/16":    (512 - 100) * 16,
+		"test_malloc_stats_bin_fragmented_bytes/4096":  4096,
+		"test_malloc_stats_bin_allocated_bytes/8":      3200,
+		"test_malloc_stats_bin_utilization_ratio/4096": 0.75,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+}
+
+func TestMallocStatsNotJemalloc(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclMallocStats: true})
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		return []byte("Stats not supported for the current allocator"), nil
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractMallocStatsMetrics(chM, c)
+		close(chM)
+	}()
+	for m := range chM {
+		t.Errorf("unexpected metric: %s", m.Desc())
+	}
+}
+
+func TestMallocStatsLive(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_URI")
+	if addr == "" {
+		t.Skipf("TEST_REDIS_URI not set - skipping")
+	}
+
+	e, _ := NewRedisExporter(addr, Options{Namespace: "test", InclMallocStats: true})
+	c, err := e.connectToRedis()
+	if err != nil {
+		t.Fatalf("couldn't connect to redis, err: %s", err)
+	}
+	defer c.Close()
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractMallocStatsMetrics(chM, c)
+		close(chM)
+	}()
+	found := false
+	for m := range chM {
+		if strings.Contains(m.Desc().String(), "malloc_stats_bin_regions") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("didn't find malloc_stats_bin_regions")
+	}
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// memoryStatsMetrics maps the top level fields of MEMORY STATS to metric names
+var memoryStatsMetrics = map[string]string{
+	"peak.allocated":                  "memory_stats_peak_allocated_bytes",
+	"total.allocated":                 "memory_stats_total_allocated_bytes",
+	"startup.allocated":               "memory_stats_startup_allocated_bytes",
+	"replication.backlog":             "memory_stats_replication_backlog_bytes",
+	"clients.slaves":                  "memory_stats_clients_replicas_bytes",
+	"clients.normal":                  "memory_stats_clients_normal_bytes",
+	"cluster.links":                   "memory_stats_cluster_links_bytes",
+	"aof.buffer":                      "memory_stats_aof_buffer_bytes",
+	"lua.caches":                      "memory_stats_lua_caches_bytes",
+	"functions.caches":                "memory_stats_functions_caches_bytes",
+	"overhead.total":                  "memory_stats_overhead_total_bytes",
+	"overhead.db.hashtable.lut":       "memory_stats_overhead_db_hashtable_lut_bytes",
+	"overhead.db.hashtable.rehashing": "memory_stats_overhead_db_hashtable_rehashing_bytes",
+	"db.dict.rehashing.count":         "memory_stats_db_dict_rehashing",
+	"keys.count":                      "memory_stats_keys",
+	"keys.bytes-per-key":              "memory_stats_keys_bytes_per_key",
+	"dataset.bytes":                   "memory_stats_dataset_bytes",
+	"dataset.percentage":              "memory_stats_dataset_percentage",
+	"peak.percentage":                 "memory_stats_peak_percentage",
+	"allocator.allocated":             "memory_stats_allocator_allocated_bytes",
+	"allocator.active":                "memory_stats_allocator_active_bytes",
+	"allocator.resident":              "memory_stats_allocator_resident_bytes",
+	"allocator.muzzy":                 "memory_stats_allocator_muzzy_bytes",
+	"allocator-fragmentation.ratio":   "memory_stats_allocator_fragmentation_ratio",
+	"allocator-fragmentation.bytes":   "memory_stats_allocator_fragmentation_bytes",
+	"allocator.rss-ratio":             "memory_stats_allocator_rss_ratio",
+	"allocator.rss-bytes":             "memory_stats_allocator_rss_bytes",
+	"rss-overhead.ratio":              "memory_stats_rss_overhead_ratio",
+	"rss-overhead.bytes":              "memory_stats_rss_overhead_bytes",
+	"fragmentation":                   "memory_stats_fragmentation_ratio",
+	"fragmentation.bytes":             "memory_stats_fragmentation_bytes",
+}
+
+// memoryDoctorIssues are the conditions reported by MEMORY DOCTOR, identified by the markers of their bullet points
+var memoryDoctorIssues = []struct {
+	issue   string
+	markers []string
+}{
+	{issue: "high_peak", markers: []string{"Peak memory:"}},
+	{issue: "high_total_rss", markers: []string{"High total RSS:"}},
+	{issue: "high_allocator_fragmentation", markers: []string{"High allocator fragmentation:"}},
+	{issue: "high_allocator_rss_overhead", markers: []string{"High allocator RSS overhead:"}},
+	{issue: "high_process_rss_overhead", markers: []string{"High process RSS overhead:"}},
+	{issue: "big_replica_buffers", markers: []string{"Big replica buffers:", "Big slave buffers:"}},
+	{issue: "big_client_buffers", markers: []string{"Big client buffers:"}},
+	{issue: "many_scripts", markers: []string{"Many scripts:"}},
+}
+
+func (e *Exporter) extractMemoryStatsMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	if stats, err := redis.Values(doRedisCmd(c, "MEMORY", "STATS")); err != nil {
+		log.Errorf("extractMemoryStatsMetrics() MEMORY STATS err: %s", err)
+	} else {
+		e.parseMemoryStats(ch, stats)
+	}
+
+	if doctor, err := redis.String(doRedisCmd(c, "MEMORY", "DOCTOR")); err != nil {
+		log.Errorf("extractMemoryStatsMetrics() MEMORY DOCTOR err: %s", err)
+	} else {
+		e.parseMemoryDoctor(ch, doctor)
+	}
+}
+
+func (e *Exporter) parseMemoryStats(ch chan<- prometheus.Metric, stats []any) {
+	for i := 0; i+1 < len(stats); i += 2 {
+		field, _ := redis.String(stats[i], nil)
+
+		if db, ok := strings.CutPrefix(field, "db."); ok {
+			if _, err := strconv.Atoi(db); err == nil {
+				e.parseMemoryStatsDb(ch, "db"+db, stats[i+1])
+				continue
+			}
+		}
+
+		metricName, ok := memoryStatsMetrics[field]
+		if !ok {
+			log.Debugf("parseMemoryStats() skipping unknown field: %s", field)
+			continue
+		}
+		val, err := memoryStatsValue(stats[i+1])
+		if err != nil {
+			log.Debugf("parseMemoryStats() couldn't parse %s: %v, err: %s", field, stats[i+1], err)
+			continue
+		}
+		e.registerConstMetricGauge(ch, metricName, val)
+	}
+}
+
+// parseMemoryStatsDb parses the per db overhead, e.g. db.0 => [overhead.hashtable.main 72 overhead.hashtable.expires 0]
+func (e *Exporter) parseMemoryStatsDb(ch chan<- prometheus.Metric, dbLabel string, reply any) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		log.Debugf("parseMemoryStatsDb() couldn't parse %s, err: %s", dbLabel, err)
+		return
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		field, _ := redis.String(values[i], nil)
+		hashtable, ok := strings.CutPrefix(field, "overhead.hashtable.")
+		if !ok {
+			continue
+		}
+		if val, err := memoryStatsValue(values[i+1]); err == nil {
+			e.registerConstMetricGauge(ch, "memory_stats_db_hashtable_overhead_bytes", val, dbLabel, hashtable)
+		}
+	}
+}
+
+// memoryStatsValue parses the integer and the (RESP2 bulk string) floating point values of MEMORY STATS
+func memoryStatsValue(reply any) (float64, error) {
+	if v, ok := reply.(int64); ok {
+		return float64(v), nil
+	}
+	return redis.Float64(reply, nil)
+}
+
+func (e *Exporter) parseMemoryDoctor(ch chan<- prometheus.Metric, doctor string) {
+	for _, condition := range memoryDoctorIssues {
+		val := 0.0
+		for _, marker := range condition.markers {
+			if strings.Contains(doctor, marker) {
+				val = 1
+				break
+			}
+		}
+		e.registerConstMetricGauge(ch, "memory_doctor_issue", val, condition.issue)
+	}
+}
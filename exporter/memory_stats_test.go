@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func collectMemoryStatsMetrics(t *testing.T, e *Exporter, f func(ch chan<- prometheus.Metric)) map[string]float64 {
+	t.Helper()
+	chM := make(chan prometheus.Metric)
+	go func() {
+		f(chM)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		for _, l := range d.GetLabel() {
+			name += "/" + l.GetValue()
+		}
+		got[name] = d.GetGauge().GetValue()
+	}
+	return got
+}
+
+func TestMemoryStatsParse(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclMemoryStats: true})
+
+	stats := []any{
+		[]byte("peak.allocated"), int64(1048576),
+		[]byte("clients.normal"), int64(20496),
+		[]byte("replication.backlog"), int64(0),
+		[]byte("functions.caches"), int64(184),
+		[]byte("db.0"), []any{[]byte("overhead.hashtable.main"), int64(72), []byte("overhead.hashtable.expires"), int64(32)},
+		[]byte("db.9"), []any{[]byte("overhead.hashtable.main"), int64(144), []byte("overhead.hashtable.expires"), int64(0)},
+		[]byte("dataset.percentage"), []byte("21.5"),
+		[]byte("peak.percentage"), []byte("99.2"),
+		[]byte("fragmentation"), []byte("3.5"),
+		[]byte("some.future.field"), int64(1),
+	}
+	got := collectMemoryStatsMetrics(t, e, func(ch chan<- prometheus.Metric) { e.parseMemoryStats(ch, stats) })
+
+	for name, want := range map[string]float64{
+		"test_memory_stats_peak_allocated_bytes":                    1048576,
+		"test_memory_stats_clients_normal_bytes":                    20496,
+		"test_memory_stats_replication_backlog_bytes":               0,
+		"test_memory_stats_functions_caches_bytes":                  184,
+		"test_memory_stats_db_hashtable_overhead_bytes/db0/main":    72,
+		"test_memory_stats_db_hashtable_overhead_bytes/db0/expires": 32,
+		"test_memory_stats_db_hashtable_overhead_bytes/db9/main":    144,
+		"test_memory_stats_dataset_percentage":                      21.5,
+		"test_memory_stats_peak_percentage":                         99.2,
+		"test_memory_stats_fragmentation_ratio":                     3.5,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+	if len(got) != 11 {
+		t.Errorf("unexpected metrics: %v", got)
+	}
+}
+
+func TestMemoryDoctorParse(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclMemoryStats: true})
+
+	doctor := `Sam, I detected a few issues in this Redis instance memory implants:
+
+ * Peak memory: In the past this instance used more than 150% the memory that is currently using. The allocator is normally not able to release memory after a peak, so you can expect to see a big fragmentation ratio, however this is actually harmless and is only due to the memory peak, and if the Redis instance Resident Set Size (RSS) is currently bigger than expected, the memory will be used as soon as you fill the Redis instance with more data. If the memory peak was only occasional and you want to try to reclaim memory, please try the MEMORY PURGE command, otherwise the only other option is to shutdown and restart the instance.
+
+ * Big client buffers: The clients output buffers are in average greater than 200 KB. Consider using "CLIENT LIST" to see the clients with big buffers.
+
+I'm here to keep you safe, Sam. I want to help you.
+`
+	got := collectMemoryStatsMetrics(t, e, func(ch chan<- prometheus.Metric) { e.parseMemoryDoctor(ch, doctor) })
+
+	if len(got) != len(memoryDoctorIssues) {
+		t.Errorf("expected a gauge per known issue, got: %v", got)
+	}
+	for issue, want := range map[string]float64{"high_peak": 1, "big_client_buffers": 1, "high_total_rss": 0, "many_scripts": 0} {
+		if got["test_memory_doctor_issue/"+issue] != want {
+			t.Errorf("issue %s: want %f, got %f", issue, want, got["test_memory_doctor_issue/"+issue])
+		}
+	}
+}
+
+func TestMemoryStatsLive(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_URI")
+	if addr == "" {
+		t.Skipf("TEST_REDIS_URI not set - skipping")
+	}
+
+	e, _ := NewRedisExporter(addr, Options{Namespace: "test", InclMemoryStats: true})
+	c, err := e.connectToRedis()
+	if err != nil {
+		t.Fatalf("couldn't connect to redis, err: %s", err)
+	}
+	defer c.Close()
+
+	got := collectMemoryStatsMetrics(t, e, func(ch chan<- prometheus.Metric) { e.extractMemoryStatsMetrics(ch, c) })
+	if _, ok := got["test_memory_stats_peak_allocated_bytes"]; !ok {
+		t.Errorf("missing test_memory_stats_peak_allocated_bytes, got: %v", got)
+	}
+	if _, ok := got["test_memory_doctor_issue/high_peak"]; !ok {
+		t.Errorf("missing test_memory_doctor_issue, got: %v", got)
+	}
+}
+
+func TestMemoryStatsMetricDescriptions(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test"})
+	for field, name := range memoryStatsMetrics {
+		if _, ok := e.metricDescriptions[name]; !ok {
+			t.Errorf("no description for %s (MEMORY STATS field %s)", name, field)
+		}
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -116,16 +118,60 @@ func (e *Exporter) registerConstSummary(ch chan<- prometheus.Metric, metric stri
 }
 
 func (e *Exporter) registerConstHistogram(ch chan<- prometheus.Metric, metric string, count uint64, sum float64, buckets map[float64]uint64, labelValues ...string) {
+	ch <- e.newConstHistogram(metric, count, sum, buckets, labelValues...)
+}
+
+func (e *Exporter) newConstHistogram(metric string, count uint64, sum float64, buckets map[float64]uint64, labelValues ...string) prometheus.Metric {
 	if e.options.AppendInstanceRoleLabel { // append instance_role label to all metrics
 		labelValues = append(labelValues, e.instanceRole)
 	}
-	histogram := prometheus.MustNewConstHistogram(
+	return prometheus.MustNewConstHistogram(
 		e.mustFindMetricDescription(metric),
 		count, sum,
 		buckets,
 		labelValues...,
 	)
-	ch <- histogram
+}
+
+/*
+newConstNativeHistogram returns a histogram with both, the classic buckets and the buckets of a native histogram
+with the given schema. Falls back to the classic buckets only if the native buckets don't add up to count.
+*/
+func (e *Exporter) newConstNativeHistogram(metric string, count uint64, sum float64, buckets map[float64]uint64, schema int32, nativeBuckets map[int]int64, labelValues ...string) prometheus.Metric {
+	classic := e.newConstHistogram(metric, count, sum, buckets, labelValues...)
+
+	if e.options.AppendInstanceRoleLabel {
+		labelValues = append(labelValues, e.instanceRole)
+	}
+	native, err := prometheus.NewConstNativeHistogram(e.mustFindMetricDescription(metric), count, sum, nativeBuckets, nil, 0, schema, 0, time.Time{}, labelValues...)
+	if err != nil {
+		log.Debugf("%s: no native histogram for %v, err: %s", metric, labelValues, err)
+		return classic
+	}
+	return &classicAndNativeHistogram{Metric: classic, native: native}
+}
+
+// classicAndNativeHistogram is a const histogram that has the buckets of a const native histogram as well
+type classicAndNativeHistogram struct {
+	prometheus.Metric
+	native prometheus.Metric
+}
+
+func (h *classicAndNativeHistogram) Write(m *dto.Metric) error {
+	if err := h.Metric.Write(m); err != nil {
+		return err
+	}
+	native := &dto.Metric{}
+	if err := h.native.Write(native); err != nil {
+		return err
+	}
+	n := native.GetHistogram()
+	m.Histogram.Schema = n.Schema
+	m.Histogram.ZeroThreshold = n.ZeroThreshold
+	m.Histogram.ZeroCount = n.ZeroCount
+	m.Histogram.PositiveSpan = n.PositiveSpan
+	m.Histogram.PositiveDelta = n.PositiveDelta
+	return nil
 }
 
 func (e *Exporter) mustFindMetricDescription(metricName string) *prometheus.Desc {
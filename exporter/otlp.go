@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	otelprometheus "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+/*
+startOtlpPush pushes the metrics of the registry (the same ones served at /metrics) every OtlpPushInterval
+to the OTLP endpoint. TLS, headers, compression and timeouts can be configured with the standard
+OTEL_EXPORTER_OTLP_* environment variables.
+*/
+func (e *Exporter) startOtlpPush() error {
+	if e.options.OtlpPushInterval <= 0 {
+		return fmt.Errorf("invalid OTLP push interval %s", e.options.OtlpPushInterval)
+	}
+
+	otlpExporter, err := newOtlpExporter(e.options.OtlpProtocol, e.options.OtlpEndpoint)
+	if err != nil {
+		return err
+	}
+
+	producer := otelprometheus.NewMetricProducer(otelprometheus.WithGatherer(e.options.Registry))
+	log.Infof("Pushing metrics via OTLP (%s) to %s every %s", e.options.OtlpProtocol, e.options.OtlpEndpoint, e.options.OtlpPushInterval)
+	go func() {
+		e.runPushLoop("OTLP", e.options.OtlpPushInterval, func(ctx context.Context) error {
+			return e.pushOtlp(ctx, producer, otlpExporter)
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := otlpExporter.Shutdown(ctx); err != nil {
+			log.Debugf("OTLP exporter shutdown err: %s", err)
+		}
+	}()
+	return nil
+}
+
+func newOtlpExporter(protocol string, endpoint string) (sdkmetric.Exporter, error) {
+	var (
+		otlpExporter sdkmetric.Exporter
+		err          error
+	)
+	switch protocol {
+	case "", "grpc":
+		otlpExporter, err = otlpmetricgrpc.New(context.Background(), otlpmetricgrpc.WithEndpointURL(endpoint))
+	case "http/protobuf":
+		if u, parseErr := url.Parse(endpoint); parseErr == nil && strings.Trim(u.Path, "/") == "" {
+			// the path of the URL is used as is, the default path of the OTLP/HTTP metrics receiver
+			u.Path = "/v1/metrics"
+			endpoint = u.String()
+		}
+		otlpExporter, err = otlpmetrichttp.New(context.Background(), otlpmetrichttp.WithEndpointURL(endpoint))
+	default:
+		return nil, fmt.Errorf("invalid OTLP protocol %q, valid options are grpc and http/protobuf", protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the OTLP exporter: %w", err)
+	}
+	return otlpExporter, nil
+}
+
+func (e *Exporter) pushOtlp(ctx context.Context, producer sdkmetric.Producer, otlpExporter sdkmetric.Exporter) error {
+	scopeMetrics, err := producer.Produce(ctx)
+	if err != nil {
+		// the producer returns the metrics it could convert along with the error
+		log.Debugf("OTLP producer err: %s", err)
+	}
+	return otlpExporter.Export(ctx, &metricdata.ResourceMetrics{
+		Resource:     e.otlpResource(scopeMetrics),
+		ScopeMetrics: scopeMetrics,
+	})
+}
+
+/*
+otlpResource returns the resource of the pushed metrics, the labels of instance_info (e.g. redis_version,
+role and run_id) are added as redis.* attributes. It's derived from every push as the role and run_id
+change with failovers and restarts.
+*/
+func (e *Exporter) otlpResource(scopeMetrics []metricdata.ScopeMetrics) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", "redis_exporter"),
+		attribute.String("service.version", e.buildInfo.Version),
+		attribute.String("redis.addr", redisAddrWithoutUserInfo(e.redisAddr)),
+	}
+
+	instanceInfo := e.options.Namespace + "_instance_info"
+	for _, sm := range scopeMetrics {
+		for _, m := range sm.Metrics {
+			gauge, ok := m.Data.(metricdata.Gauge[float64])
+			if m.Name != instanceInfo || !ok || len(gauge.DataPoints) == 0 {
+				continue
+			}
+			for _, kv := range gauge.DataPoints[0].Attributes.ToSlice() {
+				// labels appended to all metrics aren't attributes of the redis instance
+				if kv.Key == "instance_role" || strings.TrimSpace(kv.Value.AsString()) == "" {
+					continue
+				}
+				attrs = append(attrs, attribute.String("redis."+string(kv.Key), kv.Value.AsString()))
+			}
+		}
+	}
+	return resource.NewSchemaless(attrs...)
+}
+
+// redisAddrWithoutUserInfo returns the redis address without the username and password of the URI
+func redisAddrWithoutUserInfo(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "<redacted>"
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,215 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var aofManifestFileTypes = map[string]string{
+	"b": "base",
+	"i": "incr",
+	"h": "history",
+}
+
+// aofManifestFile is a line of the manifest of the multi part AOF of Redis 7, e.g.
+// file appendonly.aof.1.base.rdb seq 1 type b
+type aofManifestFile struct {
+	name     string
+	seq      int64
+	fileType string
+}
+
+type persistenceConfig struct {
+	dir            string
+	appendDirName  string
+	appendFileName string
+	dbFileName     string
+}
+
+func getPersistenceConfig(c redis.Conn, configCommandName string) (persistenceConfig, error) {
+	var cfg persistenceConfig
+	for param, val := range map[string]*string{
+		"dir":            &cfg.dir,
+		"appenddirname":  &cfg.appendDirName,
+		"appendfilename": &cfg.appendFileName,
+		"dbfilename":     &cfg.dbFileName,
+	} {
+		reply, err := redis.StringMap(doRedisCmd(c, configCommandName, "GET", param))
+		if err != nil {
+			return cfg, err
+		}
+		// appenddirname was added in Redis 7.0 and is empty before
+		*val = reply[param]
+	}
+	if cfg.dir == "" {
+		return cfg, fmt.Errorf("couldn't get the persistence dir")
+	}
+	return cfg, nil
+}
+
+/*
+parseAofManifest parses the manifest of the multi part AOF, the values are quoted
+like the arguments of redis commands if they contain spaces, e.g.
+file appendonly.aof.2.base.rdb seq 2 type b
+file "append only.aof.3.incr.aof" seq 3 type i
+*/
+func parseAofManifest(manifest string) ([]aofManifestFile, error) {
+	var files []aofManifestFile
+	scanner := bufio.NewScanner(strings.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args, err := splitAofManifestLine(line)
+		if err != nil || len(args)%2 != 0 {
+			return nil, fmt.Errorf("invalid AOF manifest line: %s", line)
+		}
+
+		var f aofManifestFile
+		for i := 0; i < len(args); i += 2 {
+			switch args[i] {
+			case "file":
+				f.name = args[i+1]
+			case "seq":
+				f.seq, err = strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid AOF manifest sequence: %s", line)
+				}
+			case "type":
+				f.fileType = aofManifestFileTypes[args[i+1]]
+			}
+		}
+		if f.name == "" || f.fileType == "" {
+			return nil, fmt.Errorf("invalid AOF manifest line: %s", line)
+		}
+		files = append(files, f)
+	}
+	return files, scanner.Err()
+}
+
+// splitAofManifestLine splits a line of the manifest by spaces, quoted values are unquoted (redis quotes them with sdscatrepr)
+func splitAofManifestLine(line string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+
+		if line[i] != '"' {
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			args = append(args, line[i:i+end])
+			i += end
+			continue
+		}
+
+		j := i + 1
+		for j < len(line) && line[j] != '"' {
+			if line[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(line) {
+			return nil, fmt.Errorf("unbalanced quotes")
+		}
+		arg, err := strconv.Unquote(line[i : j+1])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		i = j + 1
+	}
+	return args, nil
+}
+
+func (e *Exporter) extractPersistenceFilesMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	cfg, err := getPersistenceConfig(c, e.options.ConfigCommandName)
+	if err != nil {
+		log.Errorf("extractPersistenceFilesMetrics() err: %s", err)
+		return
+	}
+
+	// the dir of redis might be mounted elsewhere in the container of the exporter
+	dir := cfg.dir
+	if e.options.OverridePersistenceDir != "" {
+		dir = e.options.OverridePersistenceDir
+	}
+
+	if cfg.dbFileName != "" {
+		if info, err := os.Stat(filepath.Join(dir, cfg.dbFileName)); err != nil {
+			log.Debugf("extractPersistenceFilesMetrics() rdb file err: %s", err)
+		} else {
+			e.registerConstMetricGauge(ch, "rdb_file_size_bytes", float64(info.Size()))
+			e.registerConstMetricGauge(ch, "rdb_file_modified_timestamp_seconds", float64(info.ModTime().Unix()))
+		}
+	}
+
+	if free, total, err := getDiskUsage(dir); err != nil {
+		log.Debugf("extractPersistenceFilesMetrics() disk usage err: %s", err)
+	} else {
+		e.registerConstMetricGauge(ch, "persistence_dir_free_bytes", free)
+		e.registerConstMetricGauge(ch, "persistence_dir_total_bytes", total)
+	}
+
+	if cfg.appendDirName == "" {
+		// single file AOF before Redis 7.0
+		return
+	}
+	aofDir := filepath.Join(dir, cfg.appendDirName)
+	if e.options.OverrideAofFilePath != "" {
+		aofDir = e.options.OverrideAofFilePath
+	}
+	e.extractAofManifestMetrics(ch, aofDir, cfg.appendFileName+".manifest")
+}
+
+func (e *Exporter) extractAofManifestMetrics(ch chan<- prometheus.Metric, aofDir string, manifestName string) {
+	manifest, err := os.ReadFile(filepath.Join(aofDir, manifestName))
+	if err != nil {
+		// there is no manifest with appendonly disabled
+		log.Debugf("extractAofManifestMetrics() err: %s", err)
+		return
+	}
+	files, err := parseAofManifest(string(manifest))
+	if err != nil {
+		log.Errorf("extractAofManifestMetrics() err: %s", err)
+		return
+	}
+
+	counts := map[string]float64{"base": 0, "incr": 0, "history": 0}
+	sizes := map[string]float64{"base": 0, "incr": 0, "history": 0}
+	var baseSeq, incrSeq int64
+	for _, f := range files {
+		counts[f.fileType]++
+		// history files are deleted once the rewrite is done
+		if info, err := os.Stat(filepath.Join(aofDir, f.name)); err == nil {
+			sizes[f.fileType] += float64(info.Size())
+		}
+		switch f.fileType {
+		case "base":
+			baseSeq = max(baseSeq, f.seq)
+		case "incr":
+			incrSeq = max(incrSeq, f.seq)
+		}
+	}
+
+	for fileType, cnt := range counts {
+		e.registerConstMetricGauge(ch, "aof_manifest_files", cnt, fileType)
+		e.registerConstMetricGauge(ch, "aof_manifest_files_size_bytes", sizes[fileType], fileType)
+	}
+	// the sequence of the base file is incremented by every AOF rewrite
+	e.registerConstMetricGauge(ch, "aof_base_file_sequence", float64(baseSeq))
+	e.registerConstMetricGauge(ch, "aof_incr_file_sequence", float64(incrSeq))
+}
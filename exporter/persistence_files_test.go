@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const testAofManifest = `file appendonly.aof.2.base.rdb seq 2 type b
+file appendonly.aof.1.incr.aof seq 1 type h
+file appendonly.aof.2.incr.aof seq 2 type i
+file "appendonly.aof.3 incr.aof" seq 3 type i
+`
+
+func TestParseAofManifest(t *testing.T) {
+	files, err := parseAofManifest(testAofManifest)
+	if err != nil {
+		t.Fatalf("parseAofManifest() err: %s", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files, got: %#v", files)
+	}
+	if f := files[3]; f.name != "appendonly.aof.3 incr.aof" || f.seq != 3 || f.fileType != "incr" {
+		t.Errorf("unexpected file: %#v", f)
+	}
+	if f := files[1]; f.fileType != "history" {
+		t.Errorf("unexpected file: %#v", f)
+	}
+
+	for _, manifest := range []string{
+		"file appendonly.aof.1.base.rdb seq",
+		"file appendonly.aof.1.base.rdb seq x type b",
+		"file appendonly.aof.1.base.rdb seq 1 type x",
+		`file "appendonly.aof.1.base.rdb seq 1 type b`,
+	} {
+		if _, err := parseAofManifest(manifest); err == nil {
+			t.Errorf("expected an error for manifest: %s", manifest)
+		}
+	}
+}
+
+func TestPersistenceFilesMetrics(t *testing.T) {
+	dir := t.TempDir()
+	aofDir := filepath.Join(dir, "appendonlydir")
+	if err := os.Mkdir(aofDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"dump.rdb":                                "REDIS0011",
+		"appendonlydir/appendonly.aof.manifest":   testAofManifest,
+		"appendonlydir/appendonly.aof.2.base.rdb": "REDIS0011xxxxxxx",
+		"appendonlydir/appendonly.aof.2.incr.aof": "*1\r\n$5\r\nMULTI\r\n",
+		"appendonlydir/appendonly.aof.3 incr.aof": "*1\r\n$4\r\nPING\r\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Unix(1700000000, 0)
+	if err := os.Chtimes(filepath.Join(dir, "dump.rdb"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", InclPersistenceFiles: true, ConfigCommandName: "CONFIG"})
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		param := args[1].(string)
+		val := map[string]string{
+			"dir":            "/data",
+			"appenddirname":  "appendonlydir",
+			"appendfilename": "appendonly.aof",
+			"dbfilename":     "dump.rdb",
+		}[param]
+		return []any{[]byte(param), []byte(val)}, nil
+	}}
+	// the dir of redis is mounted elsewhere
+	e.options.OverridePersistenceDir = dir
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractPersistenceFilesMetrics(chM, c)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		desc := m.Desc().String()
+		name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+		for _, l := range d.GetLabel() {
+			name += "/" + l.GetValue()
+		}
+		got[name] = d.GetGauge().GetValue()
+	}
+
+	for name, want := range map[string]float64{
+		"test_rdb_file_size_bytes":                   9,
+		"test_rdb_file_modified_timestamp_seconds":   1700000000,
+		"test_aof_manifest_files/base":               1,
+		"test_aof_manifest_files/incr":               2,
+		"test_aof_manifest_files/history":            1,
+		"test_aof_manifest_files_size_bytes/base":    16,
+		"test_aof_manifest_files_size_bytes/incr":    29,
+		"test_aof_manifest_files_size_bytes/history": 0,
+		"test_aof_base_file_sequence":                2,
+		"test_aof_incr_file_sequence":                3,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+	if got["test_persistence_dir_total_bytes"] <= 0 || got["test_persistence_dir_free_bytes"] > got["test_persistence_dir_total_bytes"] {
+		t.Errorf("unexpected disk usage, free: %f, total: %f", got["test_persistence_dir_free_bytes"], got["test_persistence_dir_total_bytes"])
+	}
+}
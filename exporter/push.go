@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// startPushes starts pushing the metrics of the registry to the configured OTLP, remote-write, StatsD and Graphite endpoints
+func (e *Exporter) startPushes() error {
+	if e.options.OtlpEndpoint == "" && e.options.RemoteWriteURL == "" && e.options.StatsdAddress == "" && e.options.GraphiteAddress == "" {
+		return nil
+	}
+	if e.options.Registry == nil {
+		return fmt.Errorf("pushing the metrics requires a Registry")
+	}
+
+	if e.options.OtlpEndpoint != "" {
+		if err := e.startOtlpPush(); err != nil {
+			return err
+		}
+	}
+	if e.options.RemoteWriteURL != "" {
+		if err := e.startRemoteWritePush(); err != nil {
+			return err
+		}
+	}
+	return e.startSinks()
+}
+
+// runPushLoop calls push every interval until Stop() is called, every push has to finish within the interval
+func (e *Exporter) runPushLoop(name string, interval time.Duration, push func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopCh:
+			log.Debugf("%s push stopped", name)
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := push(ctx); err != nil {
+			log.Errorf("%s push err: %s", name, err)
+		}
+		cancel()
+	}
+}
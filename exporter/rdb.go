@@ -0,0 +1,634 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// a back reference of 3 bytes expands to at most 264 bytes, so LZF can't compress better than that
+const lzfMaxExpansion = 88
+
+// opcodes of the RDB format, see rdb.h of redis
+const (
+	rdbOpcodeSlotInfo      = 0xF4
+	rdbOpcodeFunctionPreGA = 0xF5
+	rdbOpcodeFunction2     = 0xF6
+	rdbOpcodeModuleAux     = 0xF7
+	rdbOpcodeIdle          = 0xF8
+	rdbOpcodeFreq          = 0xF9
+	rdbOpcodeAux           = 0xFA
+	rdbOpcodeResizeDB      = 0xFB
+	rdbOpcodeExpireTimeMs  = 0xFC
+	rdbOpcodeExpireTime    = 0xFD
+	rdbOpcodeSelectDB      = 0xFE
+	rdbOpcodeEOF           = 0xFF
+
+	rdbEncInt8  = 0
+	rdbEncInt16 = 1
+	rdbEncInt32 = 2
+	rdbEncLzf   = 3
+
+	rdbModuleOpcodeEOF    = 0
+	rdbModuleOpcodeSint   = 1
+	rdbModuleOpcodeUint   = 2
+	rdbModuleOpcodeFloat  = 3
+	rdbModuleOpcodeDouble = 4
+	rdbModuleOpcodeString = 5
+
+	rdbModuleTypeNameCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// rdbValueTypes maps the value types of the RDB format to the key type and the encoding of the value
+var rdbValueTypes = map[byte]struct{ keyType, encoding string }{
+	0:  {"string", "raw"},
+	1:  {"list", "linkedlist"},
+	2:  {"set", "hashtable"},
+	3:  {"zset", "skiplist"},
+	4:  {"hash", "hashtable"},
+	5:  {"zset", "skiplist"},
+	7:  {"module", "module"},
+	9:  {"hash", "zipmap"},
+	10: {"list", "ziplist"},
+	11: {"set", "intset"},
+	12: {"zset", "ziplist"},
+	13: {"hash", "ziplist"},
+	14: {"list", "quicklist"},
+	15: {"stream", "stream"},
+	16: {"hash", "listpack"},
+	17: {"zset", "listpack"},
+	18: {"list", "quicklist"},
+	19: {"stream", "stream"},
+	20: {"set", "listpack"},
+	21: {"stream", "stream"},
+	24: {"hash", "hashtable"},
+	25: {"hash", "listpackex"},
+}
+
+// rdbEntry is a key of an RDB file
+type rdbEntry struct {
+	db       int
+	key      string
+	keyType  string
+	encoding string
+	// serialized size of the type, the key and the value in the RDB file
+	size int64
+	// unix time in milliseconds, 0 for keys without an expiry
+	expireAt int64
+}
+
+type rdbParser struct {
+	r       *bufio.Reader
+	offset  int64
+	version int
+	aux     map[string]string
+}
+
+func newRdbParser(r io.Reader) *rdbParser {
+	return &rdbParser{r: bufio.NewReaderSize(r, 64*1024), aux: map[string]string{}}
+}
+
+/*
+parse reads an RDB file and calls fn for every key, the values are skipped without decoding them so
+the memory usage doesn't depend on the size of the values. The version and the aux fields (e.g.
+redis-ver and ctime, which precede the keys) are set on the parser while reading the file.
+*/
+func (p *rdbParser) parse(fn func(rdbEntry) error) error {
+	magic := make([]byte, 9)
+	if err := p.readFull(magic); err != nil {
+		return fmt.Errorf("couldn't read the RDB header: %w", err)
+	}
+	if string(magic[:5]) != "REDIS" {
+		return fmt.Errorf("not an RDB file, header: %q", magic)
+	}
+	version, err := strconv.Atoi(string(magic[5:]))
+	if err != nil {
+		return fmt.Errorf("invalid RDB version: %q", magic[5:])
+	}
+	p.version = version
+
+	db := 0
+	var expireAt int64
+	for {
+		start := p.offset
+		opcode, err := p.readByte()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case rdbOpcodeEOF:
+			if p.version >= 5 {
+				// the CRC64 checksum, 0 if rdbchecksum is disabled
+				if err := p.skip(8); err != nil {
+					return err
+				}
+			}
+			return nil
+		case rdbOpcodeSelectDB:
+			n, err := p.readPlainLength()
+			if err != nil {
+				return err
+			}
+			db = int(n)
+		case rdbOpcodeExpireTime:
+			b := make([]byte, 4)
+			if err := p.readFull(b); err != nil {
+				return err
+			}
+			expireAt = int64(binary.LittleEndian.Uint32(b)) * 1000
+		case rdbOpcodeExpireTimeMs:
+			b := make([]byte, 8)
+			if err := p.readFull(b); err != nil {
+				return err
+			}
+			expireAt = int64(binary.LittleEndian.Uint64(b))
+		case rdbOpcodeResizeDB:
+			err = p.skipLengths(2)
+		case rdbOpcodeAux:
+			var key, val string
+			if key, err = p.readString(); err != nil {
+				return err
+			}
+			if val, err = p.readString(); err != nil {
+				return err
+			}
+			p.aux[key] = val
+		case rdbOpcodeFreq:
+			err = p.skip(1)
+		case rdbOpcodeIdle:
+			err = p.skipLengths(1)
+		case rdbOpcodeModuleAux:
+			// the module id, the when opcode and when
+			if err = p.skipLengths(3); err == nil {
+				err = p.skipModuleValue()
+			}
+		case rdbOpcodeFunction2:
+			_, err = p.skipString()
+		case rdbOpcodeSlotInfo:
+			// slot id, slot size and expires slot size
+			err = p.skipLengths(3)
+		case rdbOpcodeFunctionPreGA:
+			return fmt.Errorf("unsupported RDB opcode %d (functions of redis 7.0 release candidates)", opcode)
+		default:
+			key, err := p.readString()
+			if err != nil {
+				return err
+			}
+			keyType, encoding, err := p.skipValue(opcode)
+			if err != nil {
+				return fmt.Errorf("couldn't read the value of key %q: %w", key, err)
+			}
+			if err := fn(rdbEntry{db: db, key: key, keyType: keyType, encoding: encoding, size: p.offset - start, expireAt: expireAt}); err != nil {
+				return err
+			}
+			expireAt = 0
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *rdbParser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	p.offset++
+	return b, nil
+}
+
+func (p *rdbParser) readFull(b []byte) error {
+	n, err := io.ReadFull(p.r, b)
+	p.offset += int64(n)
+	return unexpectedEOF(err)
+}
+
+/*
+readBytes reads n bytes, the buffer grows with the data that's actually read instead of being allocated
+upfront, so a corrupted length fails with io.ErrUnexpectedEOF instead of allocating up to 2^64 bytes.
+*/
+func (p *rdbParser) readBytes(n uint64) ([]byte, error) {
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid string length %d", n)
+	}
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, p.r, int64(n))
+	p.offset += copied
+	return buf.Bytes(), unexpectedEOF(err)
+}
+
+func (p *rdbParser) skip(n uint64) error {
+	discarded, err := p.r.Discard(int(n))
+	p.offset += int64(discarded)
+	return unexpectedEOF(err)
+}
+
+// the file is truncated if it ends before the EOF opcode
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+/*
+readLength reads a length encoded with 6, 14, 32 or 64 bits, for strings with a special encoding
+(integers or LZF compressed) encoded is true and the length is the type of the encoding
+*/
+func (p *rdbParser) readLength() (length uint64, encoded bool, err error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3F), false, nil
+	case 1:
+		next, err := p.readByte()
+		return uint64(b&0x3F)<<8 | uint64(next), false, err
+	case 2:
+		switch b {
+		case 0x80:
+			buf := make([]byte, 4)
+			err := p.readFull(buf)
+			return uint64(binary.BigEndian.Uint32(buf)), false, err
+		case 0x81:
+			buf := make([]byte, 8)
+			err := p.readFull(buf)
+			return binary.BigEndian.Uint64(buf), false, err
+		}
+		return 0, false, fmt.Errorf("unknown length encoding 0x%x", b)
+	}
+	return uint64(b & 0x3F), true, nil
+}
+
+func (p *rdbParser) readPlainLength() (uint64, error) {
+	n, encoded, err := p.readLength()
+	if err == nil && encoded {
+		err = fmt.Errorf("unexpected string encoding %d", n)
+	}
+	return n, err
+}
+
+func (p *rdbParser) skipLengths(n int) error {
+	for range n {
+		if _, err := p.readPlainLength(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rdbParser) readString() (string, error) {
+	n, encoded, err := p.readLength()
+	if err != nil {
+		return "", err
+	}
+	if !encoded {
+		b, err := p.readBytes(n)
+		return string(b), err
+	}
+
+	switch n {
+	case rdbEncInt8:
+		b, err := p.readByte()
+		return strconv.Itoa(int(int8(b))), err
+	case rdbEncInt16:
+		b := make([]byte, 2)
+		err := p.readFull(b)
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b)))), err
+	case rdbEncInt32:
+		b := make([]byte, 4)
+		err := p.readFull(b)
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b)))), err
+	case rdbEncLzf:
+		compressedLen, err := p.readPlainLength()
+		if err != nil {
+			return "", err
+		}
+		uncompressedLen, err := p.readPlainLength()
+		if err != nil {
+			return "", err
+		}
+		compressed, err := p.readBytes(compressedLen)
+		if err != nil {
+			return "", err
+		}
+		if uncompressedLen > uint64(len(compressed))*lzfMaxExpansion {
+			return "", fmt.Errorf("invalid LZF uncompressed length %d of %d compressed bytes", uncompressedLen, len(compressed))
+		}
+		b, err := lzfDecompress(compressed, int(uncompressedLen))
+		return string(b), err
+	}
+	return "", fmt.Errorf("unknown string encoding %d", n)
+}
+
+// skipString skips a string without decompressing it, intEncoded is true for strings encoded as integers
+func (p *rdbParser) skipString() (intEncoded bool, err error) {
+	n, encoded, err := p.readLength()
+	if err != nil {
+		return false, err
+	}
+	if !encoded {
+		return false, p.skip(n)
+	}
+	switch n {
+	case rdbEncInt8:
+		return true, p.skip(1)
+	case rdbEncInt16:
+		return true, p.skip(2)
+	case rdbEncInt32:
+		return true, p.skip(4)
+	case rdbEncLzf:
+		compressedLen, err := p.readPlainLength()
+		if err != nil {
+			return false, err
+		}
+		if _, err := p.readPlainLength(); err != nil {
+			return false, err
+		}
+		return false, p.skip(compressedLen)
+	}
+	return false, fmt.Errorf("unknown string encoding %d", n)
+}
+
+func (p *rdbParser) skipStrings(n uint64) error {
+	for range n {
+		if _, err := p.skipString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipValue skips the value of the given type and returns the type of the key and the encoding of the value
+func (p *rdbParser) skipValue(valueType byte) (keyType string, encoding string, err error) {
+	t, ok := rdbValueTypes[valueType]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported value type %d", valueType)
+	}
+	keyType, encoding = t.keyType, t.encoding
+
+	switch valueType {
+	case 0:
+		var intEncoded bool
+		if intEncoded, err = p.skipString(); intEncoded {
+			encoding = "int"
+		}
+	case 1, 2:
+		err = p.skipCollection(1, 0)
+	case 4:
+		err = p.skipCollection(2, 0)
+	case 3:
+		// members with doubles encoded as strings with a length of one byte
+		var n uint64
+		if n, err = p.readPlainLength(); err != nil {
+			break
+		}
+		for i := uint64(0); i < n && err == nil; i++ {
+			if _, err = p.skipString(); err != nil {
+				break
+			}
+			var l byte
+			if l, err = p.readByte(); err == nil && l < 253 {
+				err = p.skip(uint64(l))
+			}
+		}
+	case 5:
+		err = p.skipCollection(1, 8)
+	case 7:
+		keyType, err = p.skipModuleTypeValue()
+	case 9, 10, 11, 12, 13, 16, 17, 20:
+		// ziplists, intsets and listpacks are serialized as a single string
+		_, err = p.skipString()
+	case 14:
+		err = p.skipCollection(1, 0)
+	case 18:
+		// the container (plain or packed) of every node of the quicklist
+		var n uint64
+		if n, err = p.readPlainLength(); err != nil {
+			break
+		}
+		for i := uint64(0); i < n && err == nil; i++ {
+			if err = p.skipLengths(1); err == nil {
+				_, err = p.skipString()
+			}
+		}
+	case 15, 19, 21:
+		err = p.skipStream(valueType)
+	case 24:
+		// the minimum expiry of the fields, then the relative expiry, the field and the value of every field
+		if err = p.skip(8); err != nil {
+			break
+		}
+		var n uint64
+		if n, err = p.readPlainLength(); err != nil {
+			break
+		}
+		for i := uint64(0); i < n && err == nil; i++ {
+			if err = p.skipLengths(1); err == nil {
+				err = p.skipStrings(2)
+			}
+		}
+	case 25:
+		// the minimum expiry of the fields and the listpack
+		if err = p.skip(8); err == nil {
+			_, err = p.skipString()
+		}
+	}
+	return keyType, encoding, err
+}
+
+// skipCollection skips a length followed by the elements, with stringsPerElement strings and a fixed number of bytes each
+func (p *rdbParser) skipCollection(stringsPerElement uint64, bytesPerElement uint64) error {
+	n, err := p.readPlainLength()
+	if err != nil {
+		return err
+	}
+	for range n {
+		if err := p.skipStrings(stringsPerElement); err != nil {
+			return err
+		}
+		if bytesPerElement > 0 {
+			if err := p.skip(bytesPerElement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipModuleTypeValue skips the value of a module type and returns the name of the module type, e.g. graphdata
+func (p *rdbParser) skipModuleTypeValue() (string, error) {
+	moduleId, err := p.readPlainLength()
+	if err != nil {
+		return "", err
+	}
+	return moduleTypeName(moduleId), p.skipModuleValue()
+}
+
+// moduleTypeName decodes the 9 chars name of a module type from the 54 upper bits of the module id
+func moduleTypeName(moduleId uint64) string {
+	name := make([]byte, 9)
+	for i := range name {
+		name[i] = rdbModuleTypeNameCharset[(moduleId>>(10+6*(8-i)))&63]
+	}
+	return string(name)
+}
+
+// skipModuleValue skips the values saved by a module, every value is preceded by an opcode with its type
+func (p *rdbParser) skipModuleValue() error {
+	for {
+		opcode, err := p.readPlainLength()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case rdbModuleOpcodeEOF:
+			return nil
+		case rdbModuleOpcodeSint, rdbModuleOpcodeUint:
+			err = p.skipLengths(1)
+		case rdbModuleOpcodeFloat:
+			err = p.skip(4)
+		case rdbModuleOpcodeDouble:
+			err = p.skip(8)
+		case rdbModuleOpcodeString:
+			_, err = p.skipString()
+		default:
+			return fmt.Errorf("unknown module opcode %d", opcode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipStream skips a stream, valueType 19 added the first id, the max deleted id and entries added
+// (redis 7.0) and 21 the active time of the consumers (redis 7.2)
+func (p *rdbParser) skipStream(valueType byte) error {
+	// the master id and the listpack of every node of the radix tree
+	if err := p.skipCollection(2, 0); err != nil {
+		return err
+	}
+	// length and last id
+	lengths := 3
+	if valueType >= 19 {
+		// first id, max deleted id and entries added
+		lengths += 5
+	}
+	if err := p.skipLengths(lengths); err != nil {
+		return err
+	}
+
+	groups, err := p.readPlainLength()
+	if err != nil {
+		return err
+	}
+	for range groups {
+		if _, err := p.skipString(); err != nil {
+			return err
+		}
+		// last id and, since 7.0, entries read
+		lengths := 2
+		if valueType >= 19 {
+			lengths++
+		}
+		if err := p.skipLengths(lengths); err != nil {
+			return err
+		}
+
+		// the raw id and the delivery time of the entries of the PEL, followed by the delivery count
+		pel, err := p.readPlainLength()
+		if err != nil {
+			return err
+		}
+		for range pel {
+			if err := p.skip(16 + 8); err != nil {
+				return err
+			}
+			if err := p.skipLengths(1); err != nil {
+				return err
+			}
+		}
+
+		consumers, err := p.readPlainLength()
+		if err != nil {
+			return err
+		}
+		for range consumers {
+			if _, err := p.skipString(); err != nil {
+				return err
+			}
+			// seen time and active time
+			timestamps := uint64(8)
+			if valueType >= 21 {
+				timestamps += 8
+			}
+			if err := p.skip(timestamps); err != nil {
+				return err
+			}
+			// the raw ids of the PEL of the consumer
+			if err := p.skipCollection(0, 16); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lzfDecompress decompresses the LZF compressed strings of the RDB format (lzf_d.c)
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	if outLen < 0 || outLen > len(in)*lzfMaxExpansion {
+		return nil, fmt.Errorf("invalid LZF decompressed length %d of %d compressed bytes", outLen, len(in))
+	}
+	out := make([]byte, 0, outLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 {
+			// a literal run of ctrl+1 bytes
+			ctrl++
+			if i+ctrl > len(in) || len(out)+ctrl > outLen {
+				return nil, fmt.Errorf("invalid LZF literal run")
+			}
+			out = append(out, in[i:i+ctrl]...)
+			i += ctrl
+			continue
+		}
+
+		// a back reference
+		length := ctrl >> 5
+		ref := len(out) - (ctrl&0x1F)<<8 - 1
+		if length == 7 {
+			if i >= len(in) {
+				return nil, fmt.Errorf("invalid LZF back reference")
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, fmt.Errorf("invalid LZF back reference")
+		}
+		ref -= int(in[i])
+		i++
+		length += 2
+		if ref < 0 || len(out)+length > outLen {
+			return nil, fmt.Errorf("invalid LZF back reference")
+		}
+		// the reference can overlap with the output, so it's copied byte by byte
+		for j := range length {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != outLen {
+		return nil, fmt.Errorf("LZF decompressed length %d, expected %d", len(out), outLen)
+	}
+	return out, nil
+}
@@ -0,0 +1,301 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// RDBKeyStats are the statistics of the keys of a database or a key group of an RDB file
+type RDBKeyStats struct {
+	Keys int64 `json:"keys"`
+	// serialized size of the keys and values in the RDB file
+	SerializedBytes int64 `json:"serialized_bytes"`
+	ExpiringKeys    int64 `json:"expiring_keys"`
+	// number of keys per key type and per key type and encoding
+	Types      map[string]int64            `json:"types"`
+	Encodings  map[string]map[string]int64 `json:"encodings"`
+	TTLSeconds RDBHistogram                `json:"ttl_seconds"`
+
+	ttls *histogramAccumulator
+}
+
+// RDBHistogram is a histogram of an RDB analysis with the same buckets as the keys_ttl_seconds metric
+type RDBHistogram struct {
+	Count   uint64               `json:"count"`
+	Sum     float64              `json:"sum"`
+	Buckets []RDBHistogramBucket `json:"buckets"`
+}
+
+// RDBHistogramBucket is a cumulative bucket of a histogram
+type RDBHistogramBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// RDBDatabase holds the statistics of the keys of a database of an RDB file
+type RDBDatabase struct {
+	DB int `json:"db"`
+	RDBKeyStats
+	// the key groups with the biggest serialized size, the remaining ones are aggregated in the "overflow" key group
+	KeyGroups         map[string]*RDBKeyStats `json:"key_groups,omitempty"`
+	DistinctKeyGroups int64                   `json:"distinct_key_groups,omitempty"`
+}
+
+// RDBAnalysis is the result of the analysis of an RDB file by AnalyzeRDB
+type RDBAnalysis struct {
+	RdbVersion   int    `json:"rdb_version"`
+	RedisVersion string `json:"redis_version,omitempty"`
+	// unix time of the creation of the RDB file, the TTLs are relative to it
+	CreatedAt     int64          `json:"created_at,omitempty"`
+	UsedMemBytes  int64          `json:"used_mem_bytes,omitempty"`
+	AnalyzedBytes int64          `json:"analyzed_bytes"`
+	Databases     []*RDBDatabase `json:"databases"`
+}
+
+func newRDBKeyStats() *RDBKeyStats {
+	return &RDBKeyStats{
+		Types:     map[string]int64{},
+		Encodings: map[string]map[string]int64{},
+		ttls:      newHistogramAccumulator(keysTTLBuckets),
+	}
+}
+
+// add adds a key, the TTL is the remaining time to live in seconds at the creation of the RDB file or -1 without an expiry
+func (s *RDBKeyStats) add(keyType string, encoding string, size int64, ttl float64) {
+	s.Keys++
+	s.SerializedBytes += size
+	s.Types[keyType]++
+	if s.Encodings[keyType] == nil {
+		s.Encodings[keyType] = map[string]int64{}
+	}
+	s.Encodings[keyType][encoding]++
+	if ttl >= 0 {
+		s.ExpiringKeys++
+		s.ttls.observe(ttl)
+	}
+}
+
+func (s *RDBKeyStats) merge(other *RDBKeyStats) {
+	s.Keys += other.Keys
+	s.SerializedBytes += other.SerializedBytes
+	s.ExpiringKeys += other.ExpiringKeys
+	for keyType, cnt := range other.Types {
+		s.Types[keyType] += cnt
+	}
+	for keyType, encodings := range other.Encodings {
+		if s.Encodings[keyType] == nil {
+			s.Encodings[keyType] = map[string]int64{}
+		}
+		for encoding, cnt := range encodings {
+			s.Encodings[keyType][encoding] += cnt
+		}
+	}
+	s.ttls.count += other.ttls.count
+	s.ttls.sum += other.ttls.sum
+	for i, cnt := range other.ttls.counts {
+		s.ttls.counts[i] += cnt
+	}
+}
+
+func (s *RDBKeyStats) finalize() {
+	s.TTLSeconds = RDBHistogram{Count: s.ttls.count, Sum: s.ttls.sum}
+	buckets := s.ttls.buckets()
+	for _, b := range s.ttls.upperBounds {
+		s.TTLSeconds.Buckets = append(s.TTLSeconds.Buckets, RDBHistogramBucket{UpperBound: b, Count: buckets[b]})
+	}
+}
+
+/*
+AnalyzeRDB reads an RDB file and returns the number of keys, their serialized size, types, encodings and
+TTL distribution per database and, with options.CheckKeyGroups or options.KeyGroupsAutoDepth, per key group
+with the same semantics as the key groups metrics of the exporter (limited to options.MaxDistinctKeyGroups).
+*/
+func AnalyzeRDB(r io.Reader, options Options) (*RDBAnalysis, error) {
+	keyGroups, err := newKeyGroupsConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := map[int]*RDBDatabase{}
+	groups := map[int]map[string]*RDBKeyStats{}
+	var createdAt time.Time
+	p := newRdbParser(r)
+	err = p.parse(func(entry rdbEntry) error {
+		if createdAt.IsZero() {
+			// the aux fields precede the keys
+			createdAt = rdbCreationTime(p.aux)
+		}
+		db := databases[entry.db]
+		if db == nil {
+			db = &RDBDatabase{DB: entry.db, RDBKeyStats: *newRDBKeyStats()}
+			databases[entry.db] = db
+			groups[entry.db] = map[string]*RDBKeyStats{}
+		}
+
+		ttl := float64(-1)
+		if entry.expireAt > 0 {
+			// relative to the creation of the RDB file, keys that were already expired have a TTL of 0
+			ttl = max(float64(entry.expireAt-createdAt.UnixMilli())/1000, 0)
+		}
+		db.add(entry.keyType, entry.encoding, entry.size, ttl)
+
+		if keyGroups.enabled() {
+			group := keyGroups.keyGroup(entry.key)
+			if groups[entry.db][group] == nil {
+				groups[entry.db][group] = newRDBKeyStats()
+			}
+			groups[entry.db][group].add(entry.keyType, entry.encoding, entry.size, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the RDB file at offset %d: %w", p.offset, err)
+	}
+
+	analysis := &RDBAnalysis{
+		RdbVersion:    p.version,
+		RedisVersion:  p.aux["redis-ver"],
+		AnalyzedBytes: p.offset,
+	}
+	analysis.CreatedAt, _ = strconv.ParseInt(p.aux["ctime"], 10, 64)
+	analysis.UsedMemBytes, _ = strconv.ParseInt(p.aux["used-mem"], 10, 64)
+
+	for _, db := range databases {
+		db.finalize()
+		if keyGroups.enabled() {
+			db.DistinctKeyGroups = int64(len(groups[db.DB]))
+			db.KeyGroups = limitRDBKeyGroups(groups[db.DB], options.MaxDistinctKeyGroups)
+		}
+		analysis.Databases = append(analysis.Databases, db)
+	}
+	sort.Slice(analysis.Databases, func(i, j int) bool {
+		return analysis.Databases[i].DB < analysis.Databases[j].DB
+	})
+	return analysis, nil
+}
+
+// rdbCreationTime returns the time of the ctime aux field, or now for RDB files without it
+func rdbCreationTime(aux map[string]string) time.Time {
+	if ctime, err := strconv.ParseInt(aux["ctime"], 10, 64); err == nil {
+		return time.Unix(ctime, 0)
+	}
+	return time.Now()
+}
+
+// limitRDBKeyGroups aggregates the key groups exceeding maxDistinctKeyGroups in the "overflow" key group like overflowKeyGroups
+func limitRDBKeyGroups(groups map[string]*RDBKeyStats, maxDistinctKeyGroups int64) map[string]*RDBKeyStats {
+	for _, stats := range groups {
+		stats.finalize()
+	}
+
+	metrics := make(map[string]*keyGroupMetrics, len(groups))
+	for group, stats := range groups {
+		metrics[group] = &keyGroupMetrics{keyGroup: group, count: stats.Keys, memoryUsage: stats.SerializedBytes}
+	}
+	overflowed := overflowKeyGroups(metrics, maxDistinctKeyGroups)
+	if overflowed == nil {
+		return groups
+	}
+
+	res := make(map[string]*RDBKeyStats, len(overflowed.topMemoryUsageKeyGroups)+1)
+	for _, m := range overflowed.topMemoryUsageKeyGroups {
+		res[m.keyGroup] = groups[m.keyGroup]
+		delete(groups, m.keyGroup)
+	}
+	overflow := newRDBKeyStats()
+	for _, stats := range groups {
+		overflow.merge(stats)
+	}
+	overflow.finalize()
+	res[overflowed.overflowKeyGroupAggregate.keyGroup] = overflow
+	return res
+}
+
+// WriteJSON writes the analysis as indented JSON
+func (a *RDBAnalysis) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// WritePrometheus writes the analysis as metrics in the prometheus text format, e.g. for the node exporter textfile collector
+func (a *RDBAnalysis) WritePrometheus(w io.Writer, namespace string) error {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(&rdbAnalysisCollector{analysis: a, namespace: namespace}); err != nil {
+		return err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rdbAnalysisCollector struct {
+	analysis  *RDBAnalysis
+	namespace string
+}
+
+func (c *rdbAnalysisCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *rdbAnalysisCollector) Collect(ch chan<- prometheus.Metric) {
+	a := c.analysis
+	c.gauge(ch, "rdb_info", "Information about the analyzed RDB file", 1, []string{"rdb_version", "redis_version"}, strconv.Itoa(a.RdbVersion), a.RedisVersion)
+	c.gauge(ch, "rdb_analyzed_bytes", "Number of bytes of the analyzed RDB file", float64(a.AnalyzedBytes), nil)
+	if a.CreatedAt > 0 {
+		c.gauge(ch, "rdb_created_timestamp_seconds", "Creation time of the RDB file", float64(a.CreatedAt), nil)
+	}
+	if a.UsedMemBytes > 0 {
+		c.gauge(ch, "rdb_used_memory_bytes", "Memory used by redis when the RDB file was created", float64(a.UsedMemBytes), nil)
+	}
+
+	for _, db := range a.Databases {
+		dbLabel := "db" + strconv.Itoa(db.DB)
+		c.collectKeyStats(ch, "rdb_db_", "database", &db.RDBKeyStats, []string{"db"}, dbLabel)
+		if db.KeyGroups == nil {
+			continue
+		}
+		c.gauge(ch, "rdb_number_of_distinct_key_groups", "Number of distinct key groups per database of the RDB file", float64(db.DistinctKeyGroups), []string{"db"}, dbLabel)
+		for group, stats := range db.KeyGroups {
+			c.collectKeyStats(ch, "rdb_key_group_", "key group", stats, []string{"db", "key_group"}, dbLabel, group)
+		}
+	}
+}
+
+func (c *rdbAnalysisCollector) collectKeyStats(ch chan<- prometheus.Metric, prefix string, level string, s *RDBKeyStats, labels []string, labelValues ...string) {
+	c.gauge(ch, prefix+"keys", "Number of keys per "+level+" of the RDB file", float64(s.Keys), labels, labelValues...)
+	c.gauge(ch, prefix+"serialized_bytes", "Serialized size of the keys and values per "+level+" of the RDB file", float64(s.SerializedBytes), labels, labelValues...)
+	c.gauge(ch, prefix+"expiring_keys", "Number of keys with an expiry per "+level+" of the RDB file", float64(s.ExpiringKeys), labels, labelValues...)
+	for keyType, cnt := range s.Types {
+		c.gauge(ch, prefix+"keys_by_type", "Number of keys per "+level+" and key type of the RDB file", float64(cnt), append(labels, "type"), append(labelValues, keyType)...)
+		for encoding, cnt := range s.Encodings[keyType] {
+			c.gauge(ch, prefix+"keys_by_encoding", "Number of keys per "+level+", key type and encoding of the RDB file", float64(cnt), append(labels, "type", "encoding"), append(labelValues, keyType, encoding)...)
+		}
+	}
+
+	buckets := make(map[float64]uint64, len(s.TTLSeconds.Buckets))
+	for _, b := range s.TTLSeconds.Buckets {
+		buckets[b.UpperBound] = b.Count
+	}
+	desc := prometheus.NewDesc(prometheus.BuildFQName(c.namespace, "", prefix+"key_ttl_seconds"), "Remaining TTL at the creation of the RDB file of the keys with an expiry per "+level, labels, nil)
+	ch <- prometheus.MustNewConstHistogram(desc, s.TTLSeconds.Count, s.TTLSeconds.Sum, buckets, labelValues...)
+}
+
+func (c *rdbAnalysisCollector) gauge(ch chan<- prometheus.Metric, name string, help string, value float64, labels []string, labelValues ...string) {
+	desc := prometheus.NewDesc(prometheus.BuildFQName(c.namespace, "", name), help, labels, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeRDB(t *testing.T) {
+	analysis, err := AnalyzeRDB(bytes.NewReader(testRdbFile()), Options{
+		CheckKeyGroups:         "^(user):,^(session):",
+		KeyGroupsAutoDelimiter: ":",
+		KeyGroupsAutoDepth:     1,
+		MaxDistinctKeyGroups:   4,
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeRDB() err: %s", err)
+	}
+	if analysis.CreatedAt != testRdbCtime || analysis.RedisVersion != "7.2.4" || len(analysis.Databases) != 2 {
+		t.Fatalf("unexpected analysis: %#v", analysis)
+	}
+
+	db0 := analysis.Databases[0]
+	if db0.Keys != 8 || db0.ExpiringKeys != 2 || db0.Types["string"] != 3 || db0.Encodings["string"]["int"] != 1 || db0.Types["graphdata"] != 1 {
+		t.Errorf("unexpected stats of db0: %#v", db0.RDBKeyStats)
+	}
+	// the expired key has a TTL of 0 and the other one 1h
+	if db0.TTLSeconds.Count != 2 || db0.TTLSeconds.Sum != 3600 || db0.TTLSeconds.Buckets[0].Count != 1 || db0.TTLSeconds.Buckets[3].Count != 2 {
+		t.Errorf("unexpected TTLs of db0: %#v", db0.TTLSeconds)
+	}
+
+	// user, session, queue, leaderboard, social, events and aaaaaaaaaa with the 3 smallest ones in the overflow group
+	if db0.DistinctKeyGroups != 7 || len(db0.KeyGroups) != 5 {
+		t.Fatalf("unexpected key groups of db0: %v", db0.KeyGroups)
+	}
+	if g := db0.KeyGroups["user"]; g == nil || g.Keys != 2 || g.ExpiringKeys != 2 {
+		t.Errorf("unexpected key group user: %#v", g)
+	}
+	var keys int64
+	for _, g := range db0.KeyGroups {
+		keys += g.Keys
+	}
+	if keys != db0.Keys || db0.KeyGroups["overflow"] == nil {
+		t.Errorf("expected the overflow key group with the remaining keys, got: %v", db0.KeyGroups)
+	}
+
+	var out bytes.Buffer
+	if err := analysis.WritePrometheus(&out, "redis"); err != nil {
+		t.Fatalf("WritePrometheus() err: %s", err)
+	}
+	for _, want := range []string{
+		`redis_rdb_info{rdb_version="11",redis_version="7.2.4"} 1`,
+		`redis_rdb_db_keys{db="db0"} 8`,
+		`redis_rdb_db_keys{db="db3"} 1`,
+		`redis_rdb_db_keys_by_encoding{db="db0",encoding="quicklist",type="list"} 1`,
+		`redis_rdb_db_key_ttl_seconds_bucket{db="db0",le="60"} 1`,
+		`redis_rdb_key_group_keys{db="db0",key_group="user"} 2`,
+		`redis_rdb_number_of_distinct_key_groups{db="db0"} 7`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %s in the output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := analysis.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() err: %s", err)
+	}
+	var decoded RDBAnalysis
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded.Databases[1].DB != 3 || decoded.Databases[0].Keys != 8 {
+		t.Errorf("unexpected JSON output, err: %v:\n%s", err, out.String())
+	}
+
+	if _, err := AnalyzeRDB(bytes.NewReader(testRdbFile()), Options{CheckKeyGroups: "[user"}); err == nil {
+		t.Errorf("expected an error for an invalid key group regex")
+	}
+	if _, err := AnalyzeRDB(bytes.NewReader(testRdbFile()), Options{KeyGroupsAutoDepth: 1}); err == nil {
+		t.Errorf("expected an error for an empty auto-grouping delimiter")
+	}
+}
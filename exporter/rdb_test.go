@@ -0,0 +1,230 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// rdbTestWriter builds RDB files for the tests
+type rdbTestWriter struct {
+	bytes.Buffer
+}
+
+func (w *rdbTestWriter) length(n uint64) {
+	switch {
+	case n < 1<<6:
+		w.WriteByte(byte(n))
+	case n < 1<<14:
+		w.WriteByte(byte(n>>8) | 0x40)
+		w.WriteByte(byte(n))
+	case n < 1<<32:
+		w.WriteByte(0x80)
+		_ = binary.Write(w, binary.BigEndian, uint32(n))
+	default:
+		w.WriteByte(0x81)
+		_ = binary.Write(w, binary.BigEndian, n)
+	}
+}
+
+func (w *rdbTestWriter) string(s string) {
+	w.length(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (w *rdbTestWriter) key(valueType byte, key string) {
+	w.WriteByte(valueType)
+	w.string(key)
+}
+
+func (w *rdbTestWriter) expireMs(ms int64) {
+	w.WriteByte(rdbOpcodeExpireTimeMs)
+	_ = binary.Write(w, binary.LittleEndian, ms)
+}
+
+func (w *rdbTestWriter) moduleId(name string, encver uint64) uint64 {
+	var id uint64
+	for _, c := range []byte(name) {
+		id = id<<6 | uint64(strings.IndexByte(rdbModuleTypeNameCharset, c))
+	}
+	return id<<10 | encver
+}
+
+const testRdbCtime = 1700000000
+
+func testRdbFile() []byte {
+	w := &rdbTestWriter{}
+	w.WriteString("REDIS0011")
+	w.WriteByte(rdbOpcodeAux)
+	w.string("redis-ver")
+	w.string("7.2.4")
+	w.WriteByte(rdbOpcodeAux)
+	w.string("ctime")
+	w.string("1700000000")
+	w.WriteByte(rdbOpcodeFunction2)
+	w.string("#!lua name=mylib\nredis.register_function('f', function() return 1 end)")
+
+	w.WriteByte(rdbOpcodeSelectDB)
+	w.length(0)
+	w.WriteByte(rdbOpcodeResizeDB)
+	w.length(8)
+	w.length(2)
+
+	// a string encoded as int8 with an expiry in 1h
+	w.expireMs((testRdbCtime + 3600) * 1000)
+	w.key(0, "user:1:visits")
+	w.Write([]byte{0xC0, 42})
+
+	// an already expired string
+	w.expireMs((testRdbCtime - 10) * 1000)
+	w.key(0, "user:2:name")
+	w.string("bob")
+
+	// a LZF compressed key "aaaaaaaaaa" with a raw string
+	w.WriteByte(0)
+	w.Write([]byte{0xC3, 5, 10, 0x00, 'a', 0xE0, 0x00, 0x00})
+	w.string("value")
+
+	// a quicklist of two listpack nodes with LFU info
+	w.WriteByte(rdbOpcodeFreq)
+	w.WriteByte(5)
+	w.key(18, "queue:jobs")
+	w.length(2)
+	w.length(2)
+	w.string("listpack-1")
+	w.length(2)
+	w.string("listpack-2")
+
+	// a hash as hashtable and a zset as skiplist
+	w.key(4, "session:0123abcd")
+	w.length(1)
+	w.string("field")
+	w.string("value")
+	w.key(5, "leaderboard")
+	w.length(1)
+	w.string("member")
+	w.Write(make([]byte, 8))
+
+	// a FalkorDB graph
+	w.key(7, "social")
+	w.length(w.moduleId("graphdata", 14))
+	w.length(rdbModuleOpcodeUint)
+	w.length(1000)
+	w.length(rdbModuleOpcodeString)
+	w.string("node")
+	w.length(rdbModuleOpcodeDouble)
+	w.Write(make([]byte, 8))
+	w.length(rdbModuleOpcodeEOF)
+
+	// a stream with a consumer group, a pending entry and a consumer
+	w.key(21, "events")
+	w.length(1)
+	w.string(string(make([]byte, 16)))
+	w.string("listpack")
+	for range 8 {
+		w.length(1)
+	}
+	w.length(1)
+	w.string("group")
+	w.length(1)
+	w.length(1)
+	w.length(1)
+	w.length(1)
+	w.Write(make([]byte, 16+8))
+	w.length(1)
+	w.length(1)
+	w.string("consumer")
+	w.Write(make([]byte, 8+8))
+	w.length(1)
+	w.Write(make([]byte, 16))
+
+	w.WriteByte(rdbOpcodeSelectDB)
+	w.length(3)
+	w.key(11, "ids")
+	w.string("intset")
+
+	w.WriteByte(rdbOpcodeEOF)
+	w.Write(make([]byte, 8))
+	return w.Bytes()
+}
+
+func TestParseRdb(t *testing.T) {
+	p := newRdbParser(bytes.NewReader(testRdbFile()))
+	var entries []rdbEntry
+	if err := p.parse(func(entry rdbEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}); err != nil {
+		t.Fatalf("parse() err: %s", err)
+	}
+
+	if p.version != 11 || p.aux["redis-ver"] != "7.2.4" || p.offset != int64(len(testRdbFile())) {
+		t.Errorf("unexpected parser state, version: %d, aux: %v, offset: %d", p.version, p.aux, p.offset)
+	}
+
+	expected := []rdbEntry{
+		{db: 0, key: "user:1:visits", keyType: "string", encoding: "int", expireAt: (testRdbCtime + 3600) * 1000},
+		{db: 0, key: "user:2:name", keyType: "string", encoding: "raw", expireAt: (testRdbCtime - 10) * 1000},
+		{db: 0, key: "aaaaaaaaaa", keyType: "string", encoding: "raw"},
+		{db: 0, key: "queue:jobs", keyType: "list", encoding: "quicklist"},
+		{db: 0, key: "session:0123abcd", keyType: "hash", encoding: "hashtable"},
+		{db: 0, key: "leaderboard", keyType: "zset", encoding: "skiplist"},
+		{db: 0, key: "social", keyType: "graphdata", encoding: "module"},
+		{db: 0, key: "events", keyType: "stream", encoding: "stream"},
+		{db: 3, key: "ids", keyType: "set", encoding: "intset"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d keys, got: %#v", len(expected), entries)
+	}
+	for i, want := range expected {
+		got := entries[i]
+		if got.size <= 0 {
+			t.Errorf("expected a serialized size for key %s", got.key)
+		}
+		got.size = 0
+		if got != want {
+			t.Errorf("entry %d: expected %#v, got %#v", i, want, got)
+		}
+	}
+	// type, length prefixed key and the int8 encoded value
+	if entries[0].size != 1+14+2 {
+		t.Errorf("unexpected serialized size of user:1:visits: %d", entries[0].size)
+	}
+
+	truncated := testRdbFile()
+	truncated = truncated[:len(truncated)-20]
+	if err := newRdbParser(bytes.NewReader(truncated)).parse(func(rdbEntry) error { return nil }); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected an unexpected EOF error for a truncated file, got: %v", err)
+	}
+	if err := newRdbParser(strings.NewReader("*1\r\n$4\r\nPING\r\n")).parse(func(rdbEntry) error { return nil }); err == nil {
+		t.Errorf("expected an error for a file that isn't an RDB file")
+	}
+}
+
+func TestParseRdbCorruptedLengths(t *testing.T) {
+	for _, tst := range []struct {
+		name string
+		rdb  string
+	}{
+		{name: "huge key length", rdb: "REDIS0011\x00\x81\x7f\xff\xff\xff\xff\xff\xff\xff"},
+		{name: "key length overflowing int64", rdb: "REDIS0011\x00\x81\xff\xff\xff\xff\xff\xff\xff\xff"},
+		{name: "huge aux field length", rdb: "REDIS0011\xfa\x80\xff\xff\xff\xff"},
+		{name: "huge LZF compressed length", rdb: "REDIS0011\x00\xc3\x81\x7f\xff\xff\xff\xff\xff\xff\xff\x05"},
+		{name: "huge LZF uncompressed length", rdb: "REDIS0011\x00\xc3\x02\x81\xff\xff\xff\xff\xff\xff\xff\xff\x00a"},
+	} {
+		err := newRdbParser(strings.NewReader(tst.rdb)).parse(func(rdbEntry) error { return nil })
+		if err == nil {
+			t.Errorf("%s: expected an error", tst.name)
+		}
+	}
+
+	if _, err := lzfDecompress([]byte{0x00, 'a'}, -1); err == nil {
+		t.Errorf("expected an error for a negative LZF decompressed length")
+	}
+	if _, err := lzfDecompress([]byte{0x00, 'a'}, 1<<40); err == nil {
+		t.Errorf("expected an error for an LZF decompressed length exceeding the maximum expansion")
+	}
+}
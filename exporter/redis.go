@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 	"time"
@@ -40,6 +41,22 @@ func (e *Exporter) configureOptions(uri string) ([]redis.DialOption, error) {
 	return options, nil
 }
 
+// redisHostPort returns the host:port of a redis address (with or without scheme and credentials), the port defaults to 6379
+func redisHostPort(addr string) (string, error) {
+	uri := addr
+	if !strings.Contains(uri, "://") {
+		uri = "redis://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("couldn't parse the address of the redis instance: %s", redisAddrWithoutUserInfo(addr))
+	}
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), "6379"), nil
+	}
+	return u.Host, nil
+}
+
 func (e *Exporter) lookupPasswordInPasswordMap(uri string) (string, bool) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -87,6 +104,28 @@ func (e *Exporter) connectToRedis() (redis.Conn, error) {
 	return c, err
 }
 
+// connectToRedisNode connects to another node (host:port) with the scheme, credentials and TLS settings of the redis instance of the exporter
+func (e *Exporter) connectToRedisNode(addr string) (redis.Conn, error) {
+	uri := e.redisAddr
+	if !strings.Contains(uri, "://") {
+		uri = "redis://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Host = addr
+	uri = u.String()
+
+	options, err := e.configureOptions(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Trying DialURL(): %s", addr)
+	return redis.DialURL(uri, options...)
+}
+
 func (e *Exporter) connectToRedisCluster() (redis.Conn, error) {
 	uri := e.redisAddr
 	if !strings.Contains(uri, "://") {
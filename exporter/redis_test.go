@@ -152,3 +152,32 @@ func TestConnectToClusterUsingPasswordFile(t *testing.T) {
 		})
 	}
 }
+
+// fakeConn is a redis.Conn that answers Do() with the given function, for tests that don't need a redis instance.
+// Pipelined commands are answered by the same function when their replies are read with Receive().
+type fakeConn struct {
+	do      func(cmd string, args ...any) (any, error)
+	pending []func() (any, error)
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Err() error { return nil }
+
+func (c *fakeConn) Do(cmd string, args ...any) (any, error) { return c.do(cmd, args...) }
+
+func (c *fakeConn) Send(cmd string, args ...any) error {
+	c.pending = append(c.pending, func() (any, error) { return c.do(cmd, args...) })
+	return nil
+}
+
+func (c *fakeConn) Flush() error { return nil }
+
+func (c *fakeConn) Receive() (any, error) {
+	if len(c.pending) == 0 {
+		return nil, nil
+	}
+	reply := c.pending[0]
+	c.pending = c.pending[1:]
+	return reply()
+}
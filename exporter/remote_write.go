@@ -0,0 +1,431 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	remoteWriteMinBackoff = 500 * time.Millisecond
+	remoteWriteMaxBackoff = 30 * time.Second
+
+	// the timeout of a request is the push interval, but at least this long so short intervals don't time out every request
+	remoteWriteMinSendTimeout = 10 * time.Second
+)
+
+// metric types of the MetricMetadata of the remote-write protocol
+var remoteWriteMetricTypes = map[dto.MetricType]uint64{
+	dto.MetricType_COUNTER:   1,
+	dto.MetricType_GAUGE:     2,
+	dto.MetricType_HISTOGRAM: 3,
+	dto.MetricType_SUMMARY:   5,
+	dto.MetricType_UNTYPED:   0,
+}
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+type remoteWriteSeries struct {
+	labels    []remoteWriteLabel
+	value     float64
+	timestamp int64
+}
+
+type remoteWriteMetadata struct {
+	metricType uint64
+	name       string
+	help       string
+}
+
+// remoteWriteRequest is a snappy compressed WriteRequest of the gathered metrics
+type remoteWriteRequest struct {
+	body    []byte
+	samples int
+}
+
+/*
+remoteWriteQueue holds the requests that weren't sent yet, e.g. while the remote-write endpoint isn't
+reachable. It's bounded by size, the oldest requests are dropped when it's full.
+*/
+type remoteWriteQueue struct {
+	sync.Mutex
+	size     int
+	requests []*remoteWriteRequest
+	notify   chan struct{}
+
+	length         prometheus.Gauge
+	samplesSent    prometheus.Counter
+	samplesDropped prometheus.Counter
+	failures       prometheus.Counter
+}
+
+func newRemoteWriteQueue(namespace string, size int) *remoteWriteQueue {
+	return &remoteWriteQueue{
+		size:   size,
+		notify: make(chan struct{}, 1),
+		length: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "exporter_remote_write_queue_length",
+			Help: "Number of remote-write requests waiting to be sent",
+		}),
+		samplesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "exporter_remote_write_samples_sent_total",
+			Help: "Number of samples sent to the remote-write endpoint",
+		}),
+		samplesDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "exporter_remote_write_samples_dropped_total",
+			Help: "Number of samples dropped because the queue was full or the remote-write endpoint rejected them",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "exporter_remote_write_failures_total",
+			Help: "Number of failed attempts of sending a request to the remote-write endpoint",
+		}),
+	}
+}
+
+func (q *remoteWriteQueue) push(req *remoteWriteRequest) {
+	q.Lock()
+	if len(q.requests) >= q.size {
+		q.samplesDropped.Add(float64(q.requests[0].samples))
+		q.requests = q.requests[1:]
+	}
+	q.requests = append(q.requests, req)
+	q.length.Set(float64(len(q.requests)))
+	q.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *remoteWriteQueue) peek() *remoteWriteRequest {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.requests) == 0 {
+		return nil
+	}
+	return q.requests[0]
+}
+
+// remove removes req if it's still the oldest request, it might have been dropped by push in the meantime
+func (q *remoteWriteQueue) remove(req *remoteWriteRequest) {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.requests) > 0 && q.requests[0] == req {
+		q.requests = q.requests[1:]
+	}
+	q.length.Set(float64(len(q.requests)))
+}
+
+// remoteWriteError is returned for responses of the remote-write endpoint, only 5xx and 429 are retried
+type remoteWriteError struct {
+	statusCode int
+	body       string
+}
+
+func (e *remoteWriteError) Error() string {
+	return fmt.Sprintf("remote-write endpoint returned HTTP status %d: %s", e.statusCode, e.body)
+}
+
+func (e *remoteWriteError) retryable() bool {
+	return e.statusCode >= 500 || e.statusCode == http.StatusTooManyRequests
+}
+
+/*
+startRemoteWritePush gathers the metrics of the registry every RemoteWritePushInterval and queues them,
+the queue is sent by a separate goroutine which retries failed requests with an exponential backoff
+*/
+func (e *Exporter) startRemoteWritePush() error {
+	if e.options.RemoteWritePushInterval <= 0 {
+		return fmt.Errorf("invalid remote-write push interval %s", e.options.RemoteWritePushInterval)
+	}
+	if e.options.RemoteWriteQueueSize <= 0 {
+		return fmt.Errorf("invalid remote-write queue size %d", e.options.RemoteWriteQueueSize)
+	}
+	u, err := url.Parse(e.options.RemoteWriteURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid remote-write URL %q", e.options.RemoteWriteURL)
+	}
+	extraLabels, err := parseRemoteWriteExtraLabels(e.options.RemoteWriteExtraLabels, e.redisAddr)
+	if err != nil {
+		return err
+	}
+
+	q := newRemoteWriteQueue(e.options.Namespace, int(e.options.RemoteWriteQueueSize))
+	for _, c := range []prometheus.Collector{q.length, q.samplesSent, q.samplesDropped, q.failures} {
+		if err := e.options.Registry.Register(c); err != nil {
+			return fmt.Errorf("couldn't register the remote-write queue metrics: %w", err)
+		}
+	}
+
+	log.Infof("Pushing metrics via remote-write to %s every %s", u.Redacted(), e.options.RemoteWritePushInterval)
+	go e.runPushLoop("remote-write", e.options.RemoteWritePushInterval, func(ctx context.Context) error {
+		req, err := gatherRemoteWriteRequest(e.options.Registry, extraLabels, time.Now())
+		if req != nil {
+			q.push(req)
+		}
+		return err
+	})
+	go e.sendRemoteWriteQueue(q, u, &http.Client{Timeout: max(e.options.RemoteWritePushInterval, remoteWriteMinSendTimeout)})
+	return nil
+}
+
+/*
+parseRemoteWriteExtraLabels parses the comma separated list of labels added to all samples, the instance label
+defaults to the host:port of the redis address so the credentials of the URI don't end up in the samples.
+*/
+func parseRemoteWriteExtraLabels(labelsArg string, redisAddr string) ([]remoteWriteLabel, error) {
+	instance, err := redisHostPort(redisAddr)
+	if err != nil {
+		instance = redisAddrWithoutUserInfo(redisAddr)
+	}
+	labels := map[string]string{"instance": instance}
+	for label := range strings.SplitSeq(labelsArg, ",") {
+		if label = strings.TrimSpace(label); label == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(label, "=")
+		if !ok || name == "" || metricNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid remote-write label %q, expected name=value", label)
+		}
+		labels[name] = value
+	}
+
+	res := make([]remoteWriteLabel, 0, len(labels))
+	for name, value := range labels {
+		res = append(res, remoteWriteLabel{name: name, value: value})
+	}
+	return res, nil
+}
+
+func (e *Exporter) sendRemoteWriteQueue(q *remoteWriteQueue, u *url.URL, client *http.Client) {
+	backoff := remoteWriteMinBackoff
+	for {
+		req := q.peek()
+		if req == nil {
+			select {
+			case <-e.stopCh:
+				log.Debugf("remote-write sender stopped")
+				return
+			case <-q.notify:
+				continue
+			}
+		}
+
+		err := sendRemoteWriteRequest(client, u, req.body)
+		if err == nil {
+			q.samplesSent.Add(float64(req.samples))
+			q.remove(req)
+			backoff = remoteWriteMinBackoff
+			continue
+		}
+
+		q.failures.Inc()
+		var rwErr *remoteWriteError
+		if errors.As(err, &rwErr) && !rwErr.retryable() {
+			log.Errorf("remote-write request rejected, dropping %d samples: %s", req.samples, err)
+			q.samplesDropped.Add(float64(req.samples))
+			q.remove(req)
+			continue
+		}
+
+		log.Warnf("remote-write request failed, retrying in %s: %s", backoff, err)
+		select {
+		case <-e.stopCh:
+			log.Debugf("remote-write sender stopped")
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, remoteWriteMaxBackoff)
+	}
+}
+
+func sendRemoteWriteRequest(client *http.Client, u *url.URL, body []byte) error {
+	httpReq, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("User-Agent", "redis_exporter")
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if u.User != nil {
+		password, _ := u.User.Password()
+		httpReq.SetBasicAuth(u.User.Username(), password)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &remoteWriteError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// gatherRemoteWriteRequest gathers the registry and encodes the samples as a remote-write request
+func gatherRemoteWriteRequest(gatherer prometheus.Gatherer, extraLabels []remoteWriteLabel, now time.Time) (*remoteWriteRequest, error) {
+	families, err := gatherer.Gather()
+	if len(families) == 0 {
+		return nil, err
+	}
+	series, metadata := remoteWriteSeriesFromFamilies(families, extraLabels, now.UnixMilli())
+	return &remoteWriteRequest{
+		body:    snappy.Encode(nil, encodeRemoteWriteRequest(series, metadata)),
+		samples: len(series),
+	}, err
+}
+
+// remoteWriteSeriesFromFamilies converts the metric families to samples like prometheus does when scraping them
+func remoteWriteSeriesFromFamilies(families []*dto.MetricFamily, extraLabels []remoteWriteLabel, timestamp int64) ([]remoteWriteSeries, []remoteWriteMetadata) {
+	var (
+		series   []remoteWriteSeries
+		metadata []remoteWriteMetadata
+	)
+	for _, mf := range families {
+		name := mf.GetName()
+		metadata = append(metadata, remoteWriteMetadata{metricType: remoteWriteMetricTypes[mf.GetType()], name: name, help: mf.GetHelp()})
+
+		for _, m := range mf.GetMetric() {
+			add := func(suffix string, value float64, extra ...remoteWriteLabel) {
+				series = append(series, remoteWriteSeries{
+					labels:    remoteWriteSeriesLabels(name+suffix, m.GetLabel(), extraLabels, extra...),
+					value:     value,
+					timestamp: timestamp,
+				})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add("", m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					add("", q.GetValue(), remoteWriteLabel{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+				add("_sum", m.GetSummary().GetSampleSum())
+				add("_count", float64(m.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				hasInf := false
+				for _, b := range h.GetBucket() {
+					hasInf = hasInf || math.IsInf(b.GetUpperBound(), 1)
+					add("_bucket", float64(b.GetCumulativeCount()), remoteWriteLabel{name: "le", value: formatFloat(b.GetUpperBound())})
+				}
+				if !hasInf {
+					add("_bucket", float64(h.GetSampleCount()), remoteWriteLabel{name: "le", value: "+Inf"})
+				}
+				add("_sum", h.GetSampleSum())
+				add("_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return series, metadata
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// remoteWriteSeriesLabels returns the labels sorted by name as required by the remote-write protocol,
+// the labels of the metric take precedence over the extra labels
+func remoteWriteSeriesLabels(name string, metricLabels []*dto.LabelPair, extraLabels []remoteWriteLabel, extra ...remoteWriteLabel) []remoteWriteLabel {
+	labels := map[string]string{}
+	for _, l := range extraLabels {
+		labels[l.name] = l.value
+	}
+	for _, l := range metricLabels {
+		labels[l.GetName()] = l.GetValue()
+	}
+	for _, l := range extra {
+		labels[l.name] = l.value
+	}
+	labels["__name__"] = name
+
+	res := make([]remoteWriteLabel, 0, len(labels))
+	for n, v := range labels {
+		res = append(res, remoteWriteLabel{name: n, value: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].name < res[j].name
+	})
+	return res
+}
+
+/*
+encodeRemoteWriteRequest encodes a prometheus.WriteRequest protobuf message (remote-write 1.0):
+
+	message WriteRequest { repeated TimeSeries timeseries = 1; repeated MetricMetadata metadata = 3; }
+	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+	message Label { string name = 1; string value = 2; }
+	message Sample { double value = 1; int64 timestamp = 2; }
+	message MetricMetadata { MetricType type = 1; string metric_family_name = 2; string help = 4; }
+*/
+func encodeRemoteWriteRequest(series []remoteWriteSeries, metadata []remoteWriteMetadata) []byte {
+	var b []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+
+	for _, m := range metadata {
+		var mb []byte
+		mb = protowire.AppendTag(mb, 1, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, m.metricType)
+		mb = protowire.AppendTag(mb, 2, protowire.BytesType)
+		mb = protowire.AppendString(mb, m.name)
+		mb = protowire.AppendTag(mb, 4, protowire.BytesType)
+		mb = protowire.AppendString(mb, m.help)
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+	return b
+}
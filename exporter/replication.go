@@ -0,0 +1,252 @@
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// number of master_repl_offset samples kept to estimate the replication delay of the replicas
+	replOffsetSamplesMax = 64
+
+	// guards against replication loops and misconfigurations when following master_host
+	maxReplicationChainDepth = 16
+)
+
+type replicaInfo struct {
+	ip     string
+	port   string
+	state  string
+	offset float64
+	lag    float64
+}
+
+// replicationInfo holds the fields of the Replication section of INFO needed for the lag and the topology
+type replicationInfo struct {
+	role             string
+	masterHost       string
+	masterPort       string
+	masterLinkStatus string
+	masterReplId     string
+	replOffset       float64
+	replicas         []replicaInfo
+}
+
+type replOffsetSample struct {
+	ts     time.Time
+	offset float64
+}
+
+// replicationNode is a node of the tree returned by the /replication-topology endpoint
+type replicationNode struct {
+	Addr             string             `json:"addr"`
+	Role             string             `json:"role,omitempty"`
+	Depth            int                `json:"depth"`
+	ReplOffset       float64            `json:"repl_offset"`
+	LagBytes         *float64           `json:"lag_bytes,omitempty"`
+	LagSeconds       *float64           `json:"lag_seconds,omitempty"`
+	State            string             `json:"state,omitempty"`
+	MasterLinkStatus string             `json:"master_link_status,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	Replicas         []*replicationNode `json:"replicas,omitempty"`
+}
+
+type replicationTopology struct {
+	Instance string           `json:"instance"`
+	Depth    int              `json:"depth"`
+	Root     *replicationNode `json:"root"`
+}
+
+func parseReplicationInfo(info string) replicationInfo {
+	var ri replicationInfo
+	fieldClass := ""
+	for line := range strings.SplitSeq(info, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			fieldClass = line[2:]
+			continue
+		}
+		if fieldClass != "Replication" {
+			continue
+		}
+		fieldKey, fieldValue, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		switch fieldKey {
+		case "role":
+			ri.role = fieldValue
+		case "master_host":
+			ri.masterHost = fieldValue
+		case "master_port":
+			ri.masterPort = fieldValue
+		case "master_link_status":
+			ri.masterLinkStatus = fieldValue
+		case "master_replid":
+			ri.masterReplId = fieldValue
+		case "master_repl_offset":
+			ri.replOffset, _ = strconv.ParseFloat(fieldValue, 64)
+		default:
+			if offset, ip, port, state, lag, ok := parseConnectedSlaveString(fieldKey, fieldValue); ok {
+				ri.replicas = append(ri.replicas, replicaInfo{ip: ip, port: port, state: state, offset: offset, lag: lag})
+			}
+		}
+	}
+	return ri
+}
+
+/*
+extractReplicationLagMetrics exports the lag of the replicas in bytes, relative to master_repl_offset,
+and the estimated replication delay in seconds: the time since master_repl_offset was at the offset
+acknowledged by the replica, based on the samples of the previous scrapes (so it's a lower bound with
+the resolution of the scrape interval).
+*/
+func (e *Exporter) extractReplicationLagMetrics(ch chan<- prometheus.Metric, info string, now time.Time) {
+	ri := parseReplicationInfo(info)
+	if ri.role != "master" {
+		return
+	}
+
+	// the offsets of a new replication id (e.g. after a failover) aren't comparable to the samples
+	if ri.masterReplId != e.replOffsetReplId {
+		e.replOffsetSamples = nil
+		e.replOffsetReplId = ri.masterReplId
+	}
+	e.replOffsetSamples = append(e.replOffsetSamples, replOffsetSample{ts: now, offset: ri.replOffset})
+	if len(e.replOffsetSamples) > replOffsetSamplesMax {
+		e.replOffsetSamples = e.replOffsetSamples[len(e.replOffsetSamples)-replOffsetSamplesMax:]
+	}
+
+	for _, r := range ri.replicas {
+		e.registerConstMetricGauge(ch, "connected_slave_lag_bytes", max(ri.replOffset-r.offset, 0), r.ip, r.port, r.state)
+		if delay, ok := replicationDelay(e.replOffsetSamples, r.offset, now); ok {
+			e.registerConstMetricGauge(ch, "connected_slave_replication_delay_seconds", delay, r.ip, r.port, r.state)
+		}
+	}
+}
+
+// replicationDelay returns the time since the first sample of master_repl_offset that reached the offset of the replica
+func replicationDelay(samples []replOffsetSample, offset float64, now time.Time) (float64, bool) {
+	for i, s := range samples {
+		if s.offset < offset {
+			continue
+		}
+		if i == 0 && len(samples) == 1 && s.offset > offset {
+			// only the current sample, it's unknown since when the replica is behind
+			return 0, false
+		}
+		return now.Sub(s.ts).Seconds(), true
+	}
+	return 0, false
+}
+
+// extractReplicationChainDepthMetric follows master_host up to the master at the top of the replication chain
+func (e *Exporter) extractReplicationChainDepthMetric(ch chan<- prometheus.Metric, info string) {
+	_, _, depth, err := followReplicationMasters("", parseReplicationInfo(info), e.connectToRedisNode)
+	if err != nil {
+		log.Errorf("extractReplicationChainDepthMetric() err: %s", err)
+		return
+	}
+	e.registerConstMetricGauge(ch, "replication_chain_depth", float64(depth))
+}
+
+// followReplicationMasters follows master_host until it reaches a node that isn't a replica
+func followReplicationMasters(addr string, ri replicationInfo, dial func(addr string) (redis.Conn, error)) (rootAddr string, rootInfo replicationInfo, depth int, err error) {
+	visited := map[string]bool{addr: true}
+	for ri.role == "slave" {
+		addr = net.JoinHostPort(ri.masterHost, ri.masterPort)
+		if visited[addr] || depth >= maxReplicationChainDepth {
+			return "", replicationInfo{}, 0, fmt.Errorf("replication loop detected at %s", addr)
+		}
+		visited[addr] = true
+		depth++
+
+		if ri, err = getNodeReplicationInfo(addr, dial); err != nil {
+			return "", replicationInfo{}, 0, err
+		}
+	}
+	return addr, ri, depth, nil
+}
+
+func getNodeReplicationInfo(addr string, dial func(addr string) (redis.Conn, error)) (replicationInfo, error) {
+	c, err := dial(addr)
+	if err != nil {
+		return replicationInfo{}, fmt.Errorf("couldn't connect to %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	info, err := redis.String(doRedisCmd(c, "INFO", "REPLICATION"))
+	if err != nil {
+		return replicationInfo{}, fmt.Errorf("INFO REPLICATION of %s failed: %w", addr, err)
+	}
+	return parseReplicationInfo(info), nil
+}
+
+/*
+getReplicationTopology follows master_host up to the master at the top of the replication chain
+of the instance and then walks the replicas of every node down from there, so cascading replicas
+(replicas of replicas) are included.
+*/
+func getReplicationTopology(instanceAddr string, dial func(addr string) (redis.Conn, error)) (*replicationTopology, error) {
+	ri, err := getNodeReplicationInfo(instanceAddr, dial)
+	if err != nil {
+		return nil, err
+	}
+
+	rootAddr, rootInfo, depth, err := followReplicationMasters(instanceAddr, ri, dial)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &replicationNode{Addr: rootAddr}
+	addReplicationNodeInfo(root, rootInfo)
+	walkReplicas(root, rootInfo, dial, map[string]bool{rootAddr: true})
+
+	return &replicationTopology{Instance: instanceAddr, Depth: depth, Root: root}, nil
+}
+
+func addReplicationNodeInfo(node *replicationNode, ri replicationInfo) {
+	node.Role = ri.role
+	node.ReplOffset = ri.replOffset
+	node.MasterLinkStatus = ri.masterLinkStatus
+}
+
+func walkReplicas(node *replicationNode, ri replicationInfo, dial func(addr string) (redis.Conn, error), visited map[string]bool) {
+	for _, r := range ri.replicas {
+		lagBytes := max(ri.replOffset-r.offset, 0)
+		replica := &replicationNode{
+			Addr:     net.JoinHostPort(r.ip, r.port),
+			Depth:    node.Depth + 1,
+			LagBytes: &lagBytes,
+			State:    r.state,
+		}
+		if r.lag > -1 {
+			lag := r.lag
+			replica.LagSeconds = &lag
+		}
+		node.Replicas = append(node.Replicas, replica)
+
+		if visited[replica.Addr] || replica.Depth > maxReplicationChainDepth {
+			replica.Error = "replication loop detected"
+			continue
+		}
+		visited[replica.Addr] = true
+
+		replicaInfo, err := getNodeReplicationInfo(replica.Addr, dial)
+		if err != nil {
+			// the address announced by the replica might not be reachable from the exporter
+			replica.Error = err.Error()
+			continue
+		}
+		addReplicationNodeInfo(replica, replicaInfo)
+		walkReplicas(replica, replicaInfo, dial, visited)
+	}
+}
@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const testReplicationMasterInfo = `# Replication
+role:master
+connected_slaves:2
+slave0:ip=10.0.0.2,port=6379,state=online,offset=1000,lag=0
+slave1:ip=10.0.0.3,port=6379,state=online,offset=400,lag=1
+master_failover_state:no-failover
+master_replid:8a3a5ea1e2e9c7b5b4b3c2d1e0f9a8b7c6d5e4f3
+master_replid2:0000000000000000000000000000000000000000
+master_repl_offset:1000
+`
+
+func testReplicaInfo(masterHost string, replicas ...string) string {
+	return "# Replication\nrole:slave\nmaster_host:" + masterHost + "\nmaster_port:6379\nmaster_link_status:up\n" +
+		"connected_slaves:" + strconv.Itoa(len(replicas)) + "\n" + strings.Join(replicas, "\n") + "\nmaster_repl_offset:990\n"
+}
+
+// testReplicationDial returns connections answering INFO REPLICATION with the info of the node
+func testReplicationDial(nodes map[string]string) func(addr string) (redis.Conn, error) {
+	return func(addr string) (redis.Conn, error) {
+		info, ok := nodes[addr]
+		if !ok {
+			return nil, errors.New("connection refused")
+		}
+		return &fakeConn{do: func(cmd string, args ...any) (any, error) {
+			return []byte(info), nil
+		}}, nil
+	}
+}
+
+func TestReplicationLagMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test"})
+	start := time.Now()
+
+	collect := func(info string, now time.Time) map[string]float64 {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractReplicationLagMetrics(chM, info, now)
+			close(chM)
+		}()
+
+		got := map[string]float64{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			desc := m.Desc().String()
+			name := desc[strings.Index(desc, `"`)+1 : strings.Index(desc, `",`)]
+			for _, l := range d.GetLabel() {
+				name += "/" + l.GetValue()
+			}
+			got[name] = d.GetGauge().GetValue()
+		}
+		return got
+	}
+
+	// the master was at offset 300 15s ago and at 600 5s ago
+	collect(strings.Replace(testReplicationMasterInfo, "master_repl_offset:1000", "master_repl_offset:300", 1), start.Add(-15*time.Second))
+	collect(strings.Replace(testReplicationMasterInfo, "master_repl_offset:1000", "master_repl_offset:600", 1), start.Add(-5*time.Second))
+	got := collect(testReplicationMasterInfo, start)
+
+	for name, want := range map[string]float64{
+		"test_connected_slave_lag_bytes/10.0.0.2/6379/online":                 0,
+		"test_connected_slave_lag_bytes/10.0.0.3/6379/online":                 600,
+		"test_connected_slave_replication_delay_seconds/10.0.0.2/6379/online": 0,
+		"test_connected_slave_replication_delay_seconds/10.0.0.3/6379/online": 5,
+	} {
+		if v, ok := got[name]; !ok || v != want {
+			t.Errorf("metric %s: want %f, got %f (found: %t)", name, want, v, ok)
+		}
+	}
+
+	// the samples of a previous replication id are discarded
+	got = collect(strings.Replace(testReplicationMasterInfo, "master_replid:8a", "master_replid:9b", 1), start.Add(time.Second))
+	if _, ok := got["test_connected_slave_replication_delay_seconds/10.0.0.3/6379/online"]; ok {
+		t.Errorf("didn't expect a replication delay without previous samples")
+	}
+
+	if got := collect(testReplicaInfo("10.0.0.1"), start); len(got) != 0 {
+		t.Errorf("didn't expect metrics for a replica, got: %v", got)
+	}
+}
+
+func TestReplicationTopology(t *testing.T) {
+	dial := testReplicationDial(map[string]string{
+		"10.0.0.1:6379": testReplicationMasterInfo,
+		"10.0.0.2:6379": testReplicaInfo("10.0.0.1"),
+		// a replica of a replica
+		"10.0.0.3:6379": testReplicaInfo("10.0.0.1", "slave0:ip=10.0.0.4,port=6379,state=online,offset=980,lag=0"),
+		"10.0.0.4:6379": testReplicaInfo("10.0.0.3"),
+	})
+
+	topology, err := getReplicationTopology("10.0.0.4:6379", dial)
+	if err != nil {
+		t.Fatalf("getReplicationTopology() err: %s", err)
+	}
+	if topology.Depth != 2 || topology.Root.Addr != "10.0.0.1:6379" || topology.Root.Role != "master" {
+		t.Fatalf("unexpected topology: %#v", topology)
+	}
+	if len(topology.Root.Replicas) != 2 {
+		t.Fatalf("expected 2 replicas of the master, got: %#v", topology.Root.Replicas)
+	}
+
+	cascading := topology.Root.Replicas[1]
+	if cascading.Addr != "10.0.0.3:6379" || *cascading.LagBytes != 600 || *cascading.LagSeconds != 1 || len(cascading.Replicas) != 1 {
+		t.Fatalf("unexpected replica: %#v", cascading)
+	}
+	if r := cascading.Replicas[0]; r.Addr != "10.0.0.4:6379" || r.Depth != 2 || *r.LagBytes != 10 || r.Role != "slave" {
+		t.Errorf("unexpected replica of the replica: %#v", r)
+	}
+
+	// the replica of the replica is 2 hops away from the master
+	if _, _, depth, err := followReplicationMasters("", parseReplicationInfo(testReplicaInfo("10.0.0.3")), dial); err != nil || depth != 2 {
+		t.Errorf("expected a chain depth of 2, got: %d, err: %v", depth, err)
+	}
+}
+
+func TestReplicationTopologyErrors(t *testing.T) {
+	// a replica that isn't reachable from the exporter is reported in the topology
+	topology, err := getReplicationTopology("10.0.0.1:6379", testReplicationDial(map[string]string{
+		"10.0.0.1:6379": testReplicationMasterInfo,
+		"10.0.0.2:6379": testReplicaInfo("10.0.0.1"),
+	}))
+	if err != nil {
+		t.Fatalf("getReplicationTopology() err: %s", err)
+	}
+	if r := topology.Root.Replicas[1]; !strings.Contains(r.Error, "connection refused") {
+		t.Errorf("expected a connection error for the unreachable replica, got: %#v", r)
+	}
+
+	// two replicas replicating from each other
+	_, err = getReplicationTopology("10.0.0.1:6379", testReplicationDial(map[string]string{
+		"10.0.0.1:6379": testReplicaInfo("10.0.0.2"),
+		"10.0.0.2:6379": testReplicaInfo("10.0.0.1"),
+	}))
+	if err == nil || !strings.Contains(err.Error(), "replication loop") {
+		t.Errorf("expected a replication loop error, got: %v", err)
+	}
+}
+
+func TestReplicationTopologyHandlerDisabled(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test"})
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/replication-topology", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
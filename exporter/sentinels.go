@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,6 +13,22 @@ import (
 
 var reSentinelMaster = regexp.MustCompile(`^master\d+`)
 
+// sentinelEventChannels are the Sentinel pub/sub channels counted when SentinelSubscribeEvents is enabled
+var sentinelEventChannels = []string{"+switch-master", "+sdown", "+odown"}
+
+type sentinelMasterHistory struct {
+	address         string
+	configEpoch     float64
+	failovers       float64
+	lastFailover    time.Time
+	previousAddress string
+}
+
+type sentinelEventKey struct {
+	masterName string
+	event      string
+}
+
 func (e *Exporter) handleMetricsSentinel(ch chan<- prometheus.Metric, fieldKey string, fieldValue string) {
 	switch fieldKey {
 	case
@@ -38,6 +55,13 @@ func (e *Exporter) handleMetricsSentinel(ch chan<- prometheus.Metric, fieldKey s
 }
 
 func (e *Exporter) extractSentinelMetrics(ch chan<- prometheus.Metric, c redis.Conn) {
+	if e.options.SentinelSubscribeEvents {
+		e.sentinelEventsOnce.Do(func() {
+			go e.subscribe("sentinel events", sentinelEventChannels, false, e.handleSentinelEvent)
+		})
+		e.registerSentinelEventMetrics(ch)
+	}
+
 	masterDetails, err := redis.Values(doRedisCmd(c, "SENTINEL", "MASTERS"))
 	if err != nil {
 		log.Debugf("Error getting sentinel master details %s:", err)
@@ -92,6 +116,7 @@ func (e *Exporter) extractSentinelMetrics(ch chan<- prometheus.Metric, c redis.C
 		e.registerConstMetricGauge(ch, "sentinel_master_setting_down_after_milliseconds", masterDownAfterMs, masterName, masterAddr)
 		e.registerConstMetricGauge(ch, "sentinel_master_config_epoch", masterConfigEpoch, masterName, masterAddr)
 		e.registerConstMetricGauge(ch, "sentinel_master_last_ok_ping_reply_seconds", masterLastOkPingReplySeconds, masterName, masterAddr)
+		e.registerSentinelFailoverMetrics(ch, masterName, masterAddr, masterConfigEpoch)
 
 		sentinelDetails, _ := redis.Values(doRedisCmd(c, "SENTINEL", "SENTINELS", masterName))
 		log.Debugf("Sentinel details for master %s: %s", masterName, sentinelDetails)
@@ -103,6 +128,99 @@ func (e *Exporter) extractSentinelMetrics(ch chan<- prometheus.Metric, c redis.C
 	}
 }
 
+// registerSentinelFailoverMetrics compares the master's address and config epoch with the values seen
+// during the previous scrape and counts a failover if either of them changed.
+// The history lives on the Exporter instance, so the metrics are skipped for the exporters of /scrape requests.
+func (e *Exporter) registerSentinelFailoverMetrics(ch chan<- prometheus.Metric, masterName string, masterAddr string, configEpoch float64) {
+	if e.options.ExcludeSentinelFailoverMetrics {
+		return
+	}
+	h := e.trackSentinelMaster(masterName, masterAddr, configEpoch, time.Now())
+
+	e.registerConstMetric(ch, "sentinel_master_failovers_total", h.failovers, prometheus.CounterValue, masterName)
+	if !h.lastFailover.IsZero() {
+		e.registerConstMetricGauge(ch, "sentinel_master_last_failover_timestamp_seconds", float64(h.lastFailover.Unix()), masterName)
+	}
+	if h.previousAddress != "" {
+		e.registerConstMetricGauge(ch, "sentinel_master_previous_address_info", 1, masterName, masterAddr, h.previousAddress)
+	}
+}
+
+func (e *Exporter) trackSentinelMaster(masterName string, masterAddr string, configEpoch float64, now time.Time) *sentinelMasterHistory {
+	h, ok := e.sentinelMasters[masterName]
+	if !ok {
+		h = &sentinelMasterHistory{address: masterAddr, configEpoch: configEpoch}
+		e.sentinelMasters[masterName] = h
+		return h
+	}
+
+	if h.address != masterAddr || configEpoch > h.configEpoch {
+		log.Debugf("Sentinel master %s failed over, address: %s -> %s, config-epoch: %.0f -> %.0f", masterName, h.address, masterAddr, h.configEpoch, configEpoch)
+		h.failovers++
+		h.lastFailover = now
+		if h.address != masterAddr {
+			h.previousAddress = h.address
+		}
+	}
+	h.address = masterAddr
+	h.configEpoch = configEpoch
+	return h
+}
+
+func (e *Exporter) handleSentinelEvent(channel string, data []byte) {
+	masterName, ok := parseSentinelEventMasterName(channel, string(data))
+	if !ok {
+		log.Debugf("Couldn't parse sentinel event %s: %s", channel, data)
+		return
+	}
+
+	e.sentinelEventsMtx.Lock()
+	e.sentinelEventCounters[sentinelEventKey{masterName: masterName, event: strings.TrimPrefix(channel, "+")}]++
+	e.sentinelEventsMtx.Unlock()
+}
+
+func (e *Exporter) registerSentinelEventMetrics(ch chan<- prometheus.Metric) {
+	e.sentinelEventsMtx.Lock()
+	defer e.sentinelEventsMtx.Unlock()
+
+	for k, cnt := range e.sentinelEventCounters {
+		e.registerConstMetric(ch, "sentinel_events_total", cnt, prometheus.CounterValue, k.masterName, k.event)
+	}
+}
+
+/*
+valid examples:
+
+	+switch-master  mymaster 10.0.0.1 6379 10.0.0.2 6379
+	+sdown          master mymaster 10.0.0.1 6379
+	+odown          master mymaster 10.0.0.1 6379 #quorum 2/2
+	+sdown          slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379
+*/
+func parseSentinelEventMasterName(channel string, data string) (string, bool) {
+	fields := strings.Fields(data)
+
+	if channel == "+switch-master" {
+		if len(fields) < 1 {
+			return "", false
+		}
+		return fields[0], true
+	}
+
+	for i, f := range fields {
+		if f == "@" {
+			if i+1 < len(fields) {
+				return fields[i+1], true
+			}
+			return "", false
+		}
+	}
+
+	if len(fields) >= 2 && fields[0] == "master" {
+		return fields[1], true
+	}
+	return "", false
+}
+
 func (e *Exporter) extractSentinelConfig(ch chan<- prometheus.Metric, c redis.Conn) {
 	if !e.options.InclConfigMetrics {
 		return
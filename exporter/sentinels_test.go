@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
@@ -579,3 +580,156 @@ func TestSentinelScrapeAllConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestSentinelTrackMasterFailovers(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:26379", Options{Namespace: "test"})
+	if err != nil {
+		t.Fatalf("NewRedisExporter: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	for _, tst := range []struct {
+		name         string
+		addr         string
+		epoch        float64
+		wantFailover float64
+		wantPrevAddr string
+	}{
+		{name: "first scrape", addr: "10.0.0.1:6379", epoch: 1, wantFailover: 0, wantPrevAddr: ""},
+		{name: "nothing changed", addr: "10.0.0.1:6379", epoch: 1, wantFailover: 0, wantPrevAddr: ""},
+		{name: "address and epoch changed", addr: "10.0.0.2:6379", epoch: 2, wantFailover: 1, wantPrevAddr: "10.0.0.1:6379"},
+		{name: "epoch changed only", addr: "10.0.0.2:6379", epoch: 3, wantFailover: 2, wantPrevAddr: "10.0.0.1:6379"},
+		{name: "address changed only", addr: "10.0.0.3:6379", epoch: 3, wantFailover: 3, wantPrevAddr: "10.0.0.2:6379"},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			h := e.trackSentinelMaster("mymaster", tst.addr, tst.epoch, now)
+			if h.failovers != tst.wantFailover {
+				t.Errorf("failovers: want %.0f, got %.0f", tst.wantFailover, h.failovers)
+			}
+			if h.previousAddress != tst.wantPrevAddr {
+				t.Errorf("previous address: want %q, got %q", tst.wantPrevAddr, h.previousAddress)
+			}
+			if tst.wantFailover > 0 && !h.lastFailover.Equal(now) {
+				t.Errorf("last failover: want %s, got %s", now, h.lastFailover)
+			}
+		})
+	}
+}
+
+func TestSentinelFailoverMetrics(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:26379", Options{Namespace: "test"})
+	if err != nil {
+		t.Fatalf("NewRedisExporter: %v", err)
+	}
+
+	collect := func(addr string, epoch float64) map[string]*dto.Metric {
+		chM := make(chan prometheus.Metric, 8)
+		go func() {
+			e.registerSentinelFailoverMetrics(chM, "mymaster", addr, epoch)
+			close(chM)
+		}()
+		res := map[string]*dto.Metric{}
+		for m := range chM {
+			got := &dto.Metric{}
+			_ = m.Write(got)
+			for _, n := range []string{"sentinel_master_failovers_total", "sentinel_master_last_failover_timestamp_seconds", "sentinel_master_previous_address_info"} {
+				if strings.Contains(m.Desc().String(), `"test_`+n+`"`) {
+					res[n] = got
+				}
+			}
+		}
+		return res
+	}
+
+	first := collect("10.0.0.1:6379", 1)
+	if first["sentinel_master_failovers_total"].GetCounter().GetValue() != 0 {
+		t.Errorf("expected zero failovers on first scrape")
+	}
+	if _, ok := first["sentinel_master_previous_address_info"]; ok {
+		t.Errorf("didn't expect sentinel_master_previous_address_info before a failover")
+	}
+
+	second := collect("10.0.0.2:6379", 2)
+	if second["sentinel_master_failovers_total"].GetCounter().GetValue() != 1 {
+		t.Errorf("expected one failover, got: %v", second["sentinel_master_failovers_total"])
+	}
+	if _, ok := second["sentinel_master_last_failover_timestamp_seconds"]; !ok {
+		t.Errorf("missing sentinel_master_last_failover_timestamp_seconds")
+	}
+	prev, ok := second["sentinel_master_previous_address_info"]
+	if !ok {
+		t.Fatalf("missing sentinel_master_previous_address_info")
+	}
+	for _, l := range prev.GetLabel() {
+		if l.GetName() == "previous_master_address" && l.GetValue() != "10.0.0.1:6379" {
+			t.Errorf("wrong previous_master_address: %s", l.GetValue())
+		}
+	}
+
+	e.options.ExcludeSentinelFailoverMetrics = true
+	if got := collect("10.0.0.3:6379", 3); len(got) != 0 {
+		t.Errorf("didn't expect failover metrics with ExcludeSentinelFailoverMetrics, got: %v", got)
+	}
+}
+
+func TestSentinelParseEventMasterName(t *testing.T) {
+	for _, tst := range []struct {
+		channel string
+		data    string
+		want    string
+		ok      bool
+	}{
+		{channel: "+switch-master", data: "mymaster 10.0.0.1 6379 10.0.0.2 6379", want: "mymaster", ok: true},
+		{channel: "+sdown", data: "master mymaster 10.0.0.1 6379", want: "mymaster", ok: true},
+		{channel: "+odown", data: "master mymaster 10.0.0.1 6379 #quorum 2/2", want: "mymaster", ok: true},
+		{channel: "+sdown", data: "slave 10.0.0.2:6379 10.0.0.2 6379 @ othermaster 10.0.0.1 6379", want: "othermaster", ok: true},
+		{channel: "+sdown", data: "sentinel abc 10.0.0.5 26379 @", ok: false},
+		{channel: "+switch-master", data: "", ok: false},
+		{channel: "+odown", data: "garbage", ok: false},
+	} {
+		t.Run(tst.channel+" "+tst.data, func(t *testing.T) {
+			got, ok := parseSentinelEventMasterName(tst.channel, tst.data)
+			if ok != tst.ok || got != tst.want {
+				t.Errorf("want: %q %t, got: %q %t", tst.want, tst.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestSentinelEventMetrics(t *testing.T) {
+	e, err := NewRedisExporter("redis://localhost:26379", Options{Namespace: "test", SentinelSubscribeEvents: true})
+	if err != nil {
+		t.Fatalf("NewRedisExporter: %v", err)
+	}
+	e.handleSentinelEvent("+sdown", []byte("master mymaster 10.0.0.1 6379"))
+	e.handleSentinelEvent("+sdown", []byte("master mymaster 10.0.0.1 6379"))
+	e.handleSentinelEvent("+switch-master", []byte("mymaster 10.0.0.1 6379 10.0.0.2 6379"))
+	e.handleSentinelEvent("+odown", []byte("unparsable"))
+
+	chM := make(chan prometheus.Metric, 8)
+	go func() {
+		e.registerSentinelEventMetrics(chM)
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		lbls := map[string]string{}
+		for _, l := range d.GetLabel() {
+			lbls[l.GetName()] = l.GetValue()
+		}
+		got[lbls["master_name"]+"/"+lbls["event"]] = d.GetCounter().GetValue()
+	}
+
+	want := map[string]float64{"mymaster/sdown": 2, "mymaster/switch-master": 1}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: want %.0f, got %.0f", k, v, got[k])
+		}
+	}
+}
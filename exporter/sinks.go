@@ -0,0 +1,269 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maximum size of a StatsD datagram, small enough to not be fragmented on a 1500 bytes MTU
+const statsdMaxPacketSize = 1432
+
+var metricPathSegmentRE = regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
+
+/*
+metricNameTemplate flattens labels into the dot separated path of StatsD and Graphite metrics,
+e.g. "redis.{db}.{name}" renders redis_db_keys{db="db0"} as "redis.db0.redis_db_keys". Segments of
+labels a metric doesn't have are left out, the labels that aren't part of the template are sent as tags.
+*/
+type metricNameTemplate struct {
+	parts []metricNameTemplatePart
+}
+
+type metricNameTemplatePart struct {
+	literal string
+	label   string
+}
+
+func parseMetricNameTemplate(s string) (*metricNameTemplate, error) {
+	t := &metricNameTemplate{}
+	hasName := false
+	for s != "" {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			t.parts = append(t.parts, metricNameTemplatePart{literal: s})
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid metric name template, missing '}' after %q", s[start:])
+		}
+		label := s[start+1 : start+end]
+		if label == "" || metricNameRE.MatchString(label) {
+			return nil, fmt.Errorf("invalid metric name template, invalid label %q", label)
+		}
+		hasName = hasName || label == "name"
+		if start > 0 {
+			t.parts = append(t.parts, metricNameTemplatePart{literal: s[:start]})
+		}
+		t.parts = append(t.parts, metricNameTemplatePart{label: label})
+		s = s[start+end+1:]
+	}
+	if !hasName {
+		return nil, fmt.Errorf("invalid metric name template, it has to contain {name}")
+	}
+	return t, nil
+}
+
+// render returns the path of the series and the labels that aren't part of it, sorted by name
+func (t *metricNameTemplate) render(labels []remoteWriteLabel) (string, []remoteWriteLabel) {
+	values := map[string]string{}
+	for _, l := range labels {
+		if l.name == "__name__" {
+			values["name"] = l.value
+		} else {
+			values[l.name] = l.value
+		}
+	}
+
+	var sb strings.Builder
+	used := map[string]bool{}
+	for _, p := range t.parts {
+		if p.label == "" {
+			sb.WriteString(p.literal)
+			continue
+		}
+		used[p.label] = true
+		sb.WriteString(metricPathSegmentRE.ReplaceAllString(values[p.label], "_"))
+	}
+
+	// drop the empty segments of missing labels
+	var segments []string
+	for s := range strings.SplitSeq(sb.String(), ".") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	var tags []remoteWriteLabel
+	for _, l := range labels {
+		if l.name != "__name__" && !used[l.name] && l.value != "" {
+			tags = append(tags, l)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].name < tags[j].name
+	})
+	return strings.Join(segments, "."), tags
+}
+
+type sinkSample struct {
+	path  string
+	tags  []remoteWriteLabel
+	value float64
+}
+
+// gatherSinkSamples gathers the registry and flattens histograms and summaries the same way as for remote-write
+func (e *Exporter) gatherSinkSamples(template *metricNameTemplate) ([]sinkSample, error) {
+	families, err := e.options.Registry.Gather()
+	series, _ := remoteWriteSeriesFromFamilies(families, nil, 0)
+
+	samples := make([]sinkSample, 0, len(series))
+	for _, s := range series {
+		if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+			continue
+		}
+		path, tags := template.render(s.labels)
+		samples = append(samples, sinkSample{path: path, tags: tags, value: s.value})
+	}
+	return samples, err
+}
+
+func (e *Exporter) startSinks() error {
+	if e.options.StatsdAddress == "" && e.options.GraphiteAddress == "" {
+		return nil
+	}
+	if e.options.SinkPushInterval <= 0 {
+		return fmt.Errorf("invalid sink push interval %s", e.options.SinkPushInterval)
+	}
+	template, err := parseMetricNameTemplate(e.options.SinkMetricNameTemplate)
+	if err != nil {
+		return err
+	}
+
+	if e.options.StatsdAddress != "" {
+		log.Infof("Pushing metrics to StatsD at %s every %s", e.options.StatsdAddress, e.options.SinkPushInterval)
+	}
+	if e.options.GraphiteAddress != "" {
+		log.Infof("Pushing metrics to Graphite at %s every %s", e.options.GraphiteAddress, e.options.SinkPushInterval)
+	}
+	go e.runPushLoop("StatsD/Graphite", e.options.SinkPushInterval, func(ctx context.Context) error {
+		return e.pushSinks(ctx, template)
+	})
+	return nil
+}
+
+// pushSinks gathers the registry once, every gather is a full scrape of redis, and sends the samples to StatsD and Graphite
+func (e *Exporter) pushSinks(ctx context.Context, template *metricNameTemplate) error {
+	samples, err := e.gatherSinkSamples(template)
+	errs := []error{err}
+	if e.options.StatsdAddress != "" {
+		if err := e.pushStatsd(ctx, samples); err != nil {
+			errs = append(errs, fmt.Errorf("StatsD: %w", err))
+		}
+	}
+	if e.options.GraphiteAddress != "" {
+		if err := e.pushGraphite(ctx, samples); err != nil {
+			errs = append(errs, fmt.Errorf("Graphite: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var statsdTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+/*
+pushStatsd sends all samples as StatsD gauges, counters are sent as gauges as well because the exporter
+only knows their total and not the increments. The labels that aren't part of the path are sent as DogStatsD tags.
+Lines that don't fit into a single datagram are dropped.
+*/
+func (e *Exporter) pushStatsd(ctx context.Context, samples []sinkSample) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", e.options.StatsdAddress)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(bytes.TrimSuffix(packet.Bytes(), []byte("\n")))
+		packet.Reset()
+		return err
+	}
+	var dropped []string
+	for _, s := range samples {
+		for _, line := range statsdLines(s) {
+			// the trailing newline isn't sent for the last line of a packet
+			if len(line)-1 > statsdMaxPacketSize {
+				dropped = append(dropped, s.path)
+				continue
+			}
+			if packet.Len()+len(line) > statsdMaxPacketSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			packet.WriteString(line)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if len(dropped) > 0 {
+		log.Warnf("dropped %d StatsD lines longer than %d bytes, of: %s", len(dropped), statsdMaxPacketSize, strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+func statsdLines(s sinkSample) []string {
+	suffix := "|g"
+	if len(s.tags) > 0 {
+		tags := make([]string, 0, len(s.tags))
+		for _, t := range s.tags {
+			tags = append(tags, statsdTagEscaper.Replace(t.name)+":"+statsdTagEscaper.Replace(t.value))
+		}
+		suffix += "|#" + strings.Join(tags, ",")
+	}
+	line := s.path + ":" + strconv.FormatFloat(s.value, 'f', -1, 64) + suffix + "\n"
+
+	// a gauge with a leading minus sign is a decrement, negative values have to be set from 0
+	if s.value < 0 {
+		return []string{s.path + ":0" + suffix + "\n", line}
+	}
+	return []string{line}
+}
+
+var graphiteTagEscaper = strings.NewReplacer(";", "_", "~", "_", "!", "_", "^", "_", "=", "_", " ", "_", "\n", "_")
+
+// pushGraphite sends all samples via the Graphite plaintext protocol, the labels that aren't part of the path are sent as Graphite tags
+func (e *Exporter) pushGraphite(ctx context.Context, samples []sinkSample) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.options.GraphiteAddress)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var buf bytes.Buffer
+	writeGraphiteLines(&buf, samples, time.Now())
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
+
+func writeGraphiteLines(buf *bytes.Buffer, samples []sinkSample, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	for _, s := range samples {
+		buf.WriteString(s.path)
+		for _, t := range s.tags {
+			buf.WriteString(";" + graphiteTagEscaper.Replace(t.name) + "=" + graphiteTagEscaper.Replace(t.value))
+		}
+		buf.WriteString(" " + strconv.FormatFloat(s.value, 'f', -1, 64) + " " + ts + "\n")
+	}
+}
@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricNameTemplate(t *testing.T) {
+	template, err := parseMetricNameTemplate("redis.{name}.{db}.{cmd}")
+	if err != nil {
+		t.Fatalf("parseMetricNameTemplate() err: %s", err)
+	}
+
+	for _, tst := range []struct {
+		labels []remoteWriteLabel
+		path   string
+		tags   string
+	}{
+		{
+			labels: []remoteWriteLabel{{"__name__", "redis_db_keys"}, {"db", "db0"}},
+			path:   "redis.redis_db_keys.db0",
+		},
+		{
+			labels: []remoteWriteLabel{{"__name__", "redis_commands_total"}, {"cmd", "config|get"}, {"role", "master"}, {"addr", "10.0.0.1:6379"}},
+			path:   "redis.redis_commands_total.config_get",
+			tags:   "addr=10.0.0.1:6379,role=master",
+		},
+		{
+			labels: []remoteWriteLabel{{"__name__", "redis_uptime_in_seconds"}, {"empty", ""}},
+			path:   "redis.redis_uptime_in_seconds",
+		},
+	} {
+		path, tags := template.render(tst.labels)
+		var tagStrs []string
+		for _, t := range tags {
+			tagStrs = append(tagStrs, t.name+"="+t.value)
+		}
+		if path != tst.path || strings.Join(tagStrs, ",") != tst.tags {
+			t.Errorf("render(%v) = %q, %v, expected %q, %q", tst.labels, path, tagStrs, tst.path, tst.tags)
+		}
+	}
+
+	for _, s := range []string{"redis.{db}", "{name}.{db", "{name}.{}", "{name}.{a-b}"} {
+		if _, err := parseMetricNameTemplate(s); err == nil {
+			t.Errorf("expected an error for template %q", s)
+		}
+	}
+}
+
+func newTestSinkExporter(t *testing.T, opts Options) *Exporter {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	keys := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_db_keys"}, []string{"db", "role"})
+	keys.WithLabelValues("db0", "master").Set(42)
+	offset := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_offset"})
+	offset.Set(-3)
+	registry.MustRegister(keys, offset)
+
+	opts.Registry = registry
+	return &Exporter{options: opts, stopCh: make(chan struct{})}
+}
+
+func TestPushStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() err: %s", err)
+	}
+	defer conn.Close()
+
+	e := newTestSinkExporter(t, Options{StatsdAddress: conn.LocalAddr().String()})
+	template, _ := parseMetricNameTemplate("{name}.{db}")
+	samples, _ := e.gatherSinkSamples(template)
+	// doesn't fit into a datagram and is dropped
+	samples = append(samples, sinkSample{path: "test_long", tags: []remoteWriteLabel{{"key", strings.Repeat("x", statsdMaxPacketSize)}}, value: 1})
+	if err := e.pushStatsd(context.Background(), samples); err != nil {
+		t.Fatalf("pushStatsd() err: %s", err)
+	}
+
+	buf := make([]byte, statsdMaxPacketSize)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() err: %s", err)
+	}
+	want := "test_db_keys.db0:42|g|#role:master\ntest_offset:0|g\ntest_offset:-3|g"
+	if string(buf[:n]) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf[:n])
+	}
+}
+
+func TestPushGraphite(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err: %s", err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	e := newTestSinkExporter(t, Options{GraphiteAddress: l.Addr().String()})
+	template, _ := parseMetricNameTemplate("redis.{db}.{name}")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	samples, _ := e.gatherSinkSamples(template)
+	if err := e.pushGraphite(ctx, samples); err != nil {
+		t.Fatalf("pushGraphite() err: %s", err)
+	}
+
+	data := <-received
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "redis.db0.test_db_keys;role=master 42 ") || !strings.HasPrefix(lines[1], "redis.test_offset -3 ") {
+		t.Errorf("unexpected lines: %q", data)
+	}
+}
+
+func TestPushSinks(t *testing.T) {
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() err: %s", err)
+	}
+	defer udpConn.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err: %s", err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.ReadAll(conn)
+	}()
+
+	e := newTestSinkExporter(t, Options{StatsdAddress: udpConn.LocalAddr().String(), GraphiteAddress: l.Addr().String()})
+	gathers := 0
+	e.options.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: "test_gathers"}, func() float64 {
+		gathers++
+		return float64(gathers)
+	}))
+
+	template, _ := parseMetricNameTemplate("{name}")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.pushSinks(ctx, template); err != nil {
+		t.Fatalf("pushSinks() err: %s", err)
+	}
+	if gathers != 1 {
+		t.Errorf("expected the registry to be gathered once, got: %d", gathers)
+	}
+}
+
+func TestSinksInvalidOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{StatsdAddress: "localhost:8125", SinkMetricNameTemplate: "{name}"},
+		{GraphiteAddress: "localhost:2003", SinkPushInterval: time.Second, SinkMetricNameTemplate: "{db}"},
+	} {
+		if _, err := NewRedisExporter("redis://localhost:6379", opts); err == nil {
+			t.Errorf("expected an error for options %#v", opts)
+		}
+	}
+}
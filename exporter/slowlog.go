@@ -1,8 +1,12 @@
 package exporter
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
@@ -77,3 +81,91 @@ func (e *Exporter) extractSlowLogDetailsMetrics(ch chan<- prometheus.Metric, c r
 		}
 	}
 }
+
+// number of entries of SLOWLOG GET that are searched for the slowest call per command
+const slowlogExemplarEntries = 128
+
+/*
+getSlowlogExemplars returns the slowest call per command of the recent slowlog entries as exemplar of the command
+latency histograms, keyed by the command names used by LATENCY HISTOGRAM (e.g. "get" or "config|get"). The exemplar
+carries the slowlog id, the client addr and name and the arguments of the call, truncated to the 128 runes
+OpenMetrics allows for the labels of an exemplar.
+*/
+func (e *Exporter) getSlowlogExemplars(c redis.Conn) map[string]prometheus.Exemplar {
+	entries, err := redis.Values(doRedisCmd(c, "SLOWLOG", "GET", slowlogExemplarEntries))
+	if err != nil {
+		log.Debugf("SLOWLOG GET err: %s", err)
+		return nil
+	}
+
+	exemplars := map[string]prometheus.Exemplar{}
+	for _, entry := range entries {
+		// id, timestamp, duration in usec, args, client addr and client name (the last two since redis 4.0)
+		values, err := redis.Values(entry, nil)
+		if err != nil || len(values) < 4 {
+			continue
+		}
+		id, _ := redis.Int64(values[0], nil)
+		ts, _ := redis.Int64(values[1], nil)
+		usec, _ := redis.Int64(values[2], nil)
+		args, _ := redis.Strings(values[3], nil)
+		if len(args) == 0 {
+			continue
+		}
+		var clientAddr, clientName string
+		if len(values) >= 6 {
+			clientAddr, _ = redis.String(values[4], nil)
+			clientName, _ = redis.String(values[5], nil)
+		}
+
+		// LATENCY HISTOGRAM tracks the subcommands of e.g. CONFIG as "config|get", the entry is a candidate for both
+		exemplar := prometheus.Exemplar{
+			Value:     float64(usec),
+			Timestamp: time.Unix(ts, 0),
+			Labels:    slowlogExemplarLabels(id, clientAddr, clientName, args),
+		}
+		cmds := []string{strings.ToLower(args[0])}
+		if len(args) > 1 {
+			cmds = append(cmds, cmds[0]+"|"+strings.ToLower(args[1]))
+		}
+		for _, cmd := range cmds {
+			if prev, ok := exemplars[cmd]; !ok || prev.Value < exemplar.Value {
+				exemplars[cmd] = exemplar
+			}
+		}
+	}
+	return exemplars
+}
+
+/*
+slowlogExemplarLabels doesn't include the arguments of the call, they can contain credentials (e.g. of AUTH or
+MIGRATE) and values, only the command name and a short hash of the arguments to tell repeated calls apart
+*/
+func slowlogExemplarLabels(id int64, clientAddr, clientName string, args []string) prometheus.Labels {
+	argsHash := sha256.Sum256([]byte(strings.Join(args[1:], "\x00")))
+	labels := prometheus.Labels{
+		"slowlog_id":  strconv.FormatInt(id, 10),
+		"client_addr": truncateRunes(clientAddr, 32),
+		"client_name": truncateRunes(clientName, 16),
+		"args_hash":   hex.EncodeToString(argsHash[:8]),
+	}
+	budget := prometheus.ExemplarMaxRunes - utf8.RuneCountInString("cmd")
+	for name, value := range labels {
+		budget -= utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	labels["cmd"] = truncateRunes(strings.ToLower(args[0]), budget)
+	return labels
+}
+
+// truncateRunes replaces invalid UTF-8 (e.g. of binary arguments) and truncates s to n runes, marking the truncation with "..."
+func truncateRunes(s string, n int) string {
+	s = strings.ToValidUTF8(s, "?")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:n-3]) + "..."
+}
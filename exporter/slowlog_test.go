@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
@@ -137,3 +138,37 @@ func resetSlowLog(t *testing.T, addr string) error {
 
 	return nil
 }
+
+func TestSlowlogExemplarLabels(t *testing.T) {
+	args := []string{"SET", strings.Repeat("k", 50), strings.Repeat("v", 200), "\xff\xfe"}
+	labels := slowlogExemplarLabels(123456, "[2001:db8::1]:6379", strings.Repeat("n", 40), args)
+
+	runes := 0
+	for name, value := range labels {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+		if strings.Contains(value, "kkk") || strings.Contains(value, "vvv") {
+			t.Errorf("expected no arguments in label %s, got: %q", name, value)
+		}
+	}
+	if runes > prometheus.ExemplarMaxRunes {
+		t.Errorf("expected the labels to use at most %d runes, got: %d", prometheus.ExemplarMaxRunes, runes)
+	}
+	if labels["cmd"] != "set" || len(labels["args_hash"]) != 16 {
+		t.Errorf("unexpected cmd or args hash: %v", labels)
+	}
+	if labels["client_name"] != strings.Repeat("n", 13)+"..." || labels["slowlog_id"] != "123456" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+
+	other := slowlogExemplarLabels(123456, "[2001:db8::1]:6379", strings.Repeat("n", 40), []string{"SET", "k", "v"})
+	if other["args_hash"] == labels["args_hash"] {
+		t.Errorf("expected a different hash for different arguments, got: %s", other["args_hash"])
+	}
+
+	if _, err := prometheus.NewMetricWithExemplars(
+		prometheus.MustNewConstHistogram(prometheus.NewDesc("test", "", nil, nil), 1, 1, map[float64]uint64{1: 1}),
+		prometheus.Exemplar{Value: 1, Labels: slowlogExemplarLabels(1, "", "", []string{"GET", "\xff"})},
+	); err != nil {
+		t.Errorf("expected valid exemplar labels for binary args, err: %s", err)
+	}
+}
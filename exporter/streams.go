@@ -1,8 +1,10 @@
 package exporter
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,21 +20,39 @@ type streamInfo struct {
 	LastGeneratedId   string `redis:"last-generated-id"`
 	Groups            int64  `redis:"groups"`
 	MaxDeletedEntryId string `redis:"max-deleted-entry-id"`
+	EntriesAdded      int64  `redis:"entries-added"`
 	FirstEntryId      string
 	LastEntryId       string
 	StreamGroupsInfo  []streamGroupsInfo
 }
 
 type streamGroupsInfo struct {
-	Name                     string `redis:"name"`
-	Consumers                int64  `redis:"consumers"`
-	Pending                  int64  `redis:"pending"`
-	LastDeliveredId          string `redis:"last-delivered-id"`
-	EntriesRead              int64  `redis:"entries-read"`
-	Lag                      int64  `redis:"lag"`
+	Name            string `redis:"name"`
+	Consumers       int64  `redis:"consumers"`
+	Pending         int64  `redis:"pending"`
+	LastDeliveredId string `redis:"last-delivered-id"`
+	EntriesRead     int64  `redis:"entries-read"`
+	Lag             int64  `redis:"lag"`
+	// redis returns a nil lag when it can't compute it, e.g. after entries were deleted with XDEL
+	LagUnknown               bool `redis:"-"`
 	StreamGroupConsumersInfo []streamGroupConsumersInfo
 }
 
+// streamGroupPendingInfo summarizes the pending entries list (PEL) of a consumer group as returned by XPENDING
+type streamGroupPendingInfo struct {
+	OldestEntryId          string
+	MaxDeliveryCount       int64
+	MaxIdle                int64
+	EntriesAboveDeliveries int64
+}
+
+// streamProductionSample is the number of entries added to a stream as seen during a scrape,
+// used to derive the production rate between two scrapes
+type streamProductionSample struct {
+	entriesAdded int64
+	ts           time.Time
+}
+
 type streamGroupConsumersInfo struct {
 	Name    string `redis:"name"`
 	Pending int64  `redis:"pending"`
@@ -114,6 +134,11 @@ func scanStreamGroups(c redis.Conn, stream string) ([]streamGroupsInfo, error) {
 			log.Errorf("Couldn't scan group in stream '%s': %s", stream, err)
 			continue
 		}
+		for i := 0; i+1 < len(v); i += 2 {
+			if name, _ := redis.String(v[i], nil); name == "lag" && v[i+1] == nil {
+				group.LagUnknown = true
+			}
+		}
 
 		group.StreamGroupConsumersInfo, err = scanStreamGroupConsumers(c, stream, group.Name)
 		if err != nil {
@@ -156,6 +181,68 @@ func scanStreamGroupConsumers(c redis.Conn, stream string, group string) ([]stre
 	return result, nil
 }
 
+// getStreamGroupPendingInfo pages through the pending entries of a group with the extended form of XPENDING,
+// batchSize entries at a time, and counts the entries that were delivered more than deliveryThreshold times.
+func getStreamGroupPendingInfo(c redis.Conn, stream string, group string, batchSize int64, deliveryThreshold int64) (*streamGroupPendingInfo, error) {
+	info := &streamGroupPendingInfo{}
+	start := "-"
+	for {
+		entries, err := redis.Values(doRedisCmd(c, "XPENDING", stream, group, start, "+", batchSize))
+		if err != nil {
+			return nil, err
+		}
+
+		var lastId string
+		for _, entry := range entries {
+			var id, consumer string
+			var idle, deliveries int64
+			v, err := redis.Values(entry, nil)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := redis.Scan(v, &id, &consumer, &idle, &deliveries); err != nil {
+				return nil, fmt.Errorf("couldn't parse XPENDING entry of group '%s' in stream '%s': %s", group, stream, err)
+			}
+
+			if info.OldestEntryId == "" {
+				info.OldestEntryId = id
+			}
+			info.MaxDeliveryCount = max(info.MaxDeliveryCount, deliveries)
+			info.MaxIdle = max(info.MaxIdle, idle)
+			if deliveries > deliveryThreshold {
+				info.EntriesAboveDeliveries++
+			}
+			lastId = id
+		}
+
+		if int64(len(entries)) < batchSize || lastId == "" {
+			break
+		}
+		// exclusive range start, supported since Redis 6.2
+		start = "(" + lastId
+	}
+
+	log.Debugf("getStreamGroupPendingInfo() stream: %s group: %s info: %#v", stream, group, info)
+	return info, nil
+}
+
+// streamGroupLagSeconds returns the time difference between the newest entry of the stream and the last
+// entry delivered to the group. If nothing has been delivered yet the first entry of the stream is used instead.
+func streamGroupLagSeconds(info *streamInfo, g streamGroupsInfo) float64 {
+	if g.Lag == 0 {
+		return 0
+	}
+	lastGenerated := parseStreamItemId(info.LastGeneratedId)
+	lastDelivered := parseStreamItemId(g.LastDeliveredId)
+	if lastDelivered == 0 {
+		lastDelivered = parseStreamItemId(info.FirstEntryId)
+	}
+	if lastDelivered == 0 || lastGenerated < lastDelivered {
+		return 0
+	}
+	return (lastGenerated - lastDelivered) / 1e3
+}
+
 func parseStreamItemId(id string) float64 {
 	if strings.TrimSpace(id) == "" {
 		return 0
@@ -194,6 +281,12 @@ func (e *Exporter) extractStreamMetrics(ch chan<- prometheus.Metric, c redis.Con
 	}
 
 	log.Debugf("allStreams: %#v", allStreams)
+	productionSamples := make(map[dbKeyPair]streamProductionSample, len(allStreams))
+	defer func() {
+		// only keep the streams seen during this scrape
+		e.streamProductionSamples = productionSamples
+	}()
+
 	for _, k := range allStreams {
 		if _, err := doRedisCmd(c, "SELECT", k.db); err != nil {
 			log.Debugf("Couldn't select database '%s' when getting stream info", k.db)
@@ -214,6 +307,17 @@ func (e *Exporter) extractStreamMetrics(ch chan<- prometheus.Metric, c redis.Con
 		e.registerConstMetricGauge(ch, "stream_max_deleted_entry_id", parseStreamItemId(info.MaxDeletedEntryId), dbLabel, k.key)
 		e.registerConstMetricGauge(ch, "stream_first_entry_id", parseStreamItemId(info.FirstEntryId), dbLabel, k.key)
 		e.registerConstMetricGauge(ch, "stream_last_entry_id", parseStreamItemId(info.LastEntryId), dbLabel, k.key)
+		e.registerConstMetric(ch, "stream_entries_added_total", float64(info.EntriesAdded), prometheus.CounterValue, dbLabel, k.key)
+
+		if !e.options.StreamsExcludeProductionRate {
+			sample := streamProductionSample{entriesAdded: info.EntriesAdded, ts: time.Now()}
+			if prev, ok := e.streamProductionSamples[k]; ok {
+				if elapsed := sample.ts.Sub(prev.ts).Seconds(); elapsed > 0 && sample.entriesAdded >= prev.entriesAdded {
+					e.registerConstMetricGauge(ch, "stream_entries_added_per_second", float64(sample.entriesAdded-prev.entriesAdded)/elapsed, dbLabel, k.key)
+				}
+			}
+			productionSamples[k] = sample
+		}
 
 		for _, g := range info.StreamGroupsInfo {
 			e.registerConstMetricGauge(ch, "stream_group_consumers", float64(g.Consumers), dbLabel, k.key, g.Name)
@@ -221,6 +325,12 @@ func (e *Exporter) extractStreamMetrics(ch chan<- prometheus.Metric, c redis.Con
 			e.registerConstMetricGauge(ch, "stream_group_last_delivered_id", parseStreamItemId(g.LastDeliveredId), dbLabel, k.key, g.Name)
 			e.registerConstMetricGauge(ch, "stream_group_entries_read", float64(g.EntriesRead), dbLabel, k.key, g.Name)
 			e.registerConstMetricGauge(ch, "stream_group_lag", float64(g.Lag), dbLabel, k.key, g.Name)
+			if !g.LagUnknown {
+				e.registerConstMetricGauge(ch, "stream_group_lag_seconds", streamGroupLagSeconds(info, g), dbLabel, k.key, g.Name)
+			}
+			if e.options.StreamsInclPendingSummary {
+				e.extractStreamGroupPendingMetrics(ch, c, dbLabel, k.key, g)
+			}
 			if !e.options.StreamsExcludeConsumerMetrics {
 				for _, c := range g.StreamGroupConsumersInfo {
 					e.registerConstMetricGauge(ch, "stream_group_consumer_messages_pending", float64(c.Pending), dbLabel, k.key, g.Name, c.Name)
@@ -230,3 +340,28 @@ func (e *Exporter) extractStreamMetrics(ch chan<- prometheus.Metric, c redis.Con
 		}
 	}
 }
+
+func (e *Exporter) extractStreamGroupPendingMetrics(ch chan<- prometheus.Metric, c redis.Conn, dbLabel string, stream string, g streamGroupsInfo) {
+	if g.Pending == 0 {
+		e.registerConstMetricGauge(ch, "stream_group_pending_oldest_entry_age_seconds", 0, dbLabel, stream, g.Name)
+		e.registerConstMetricGauge(ch, "stream_group_pending_max_delivery_count", 0, dbLabel, stream, g.Name)
+		e.registerConstMetricGauge(ch, "stream_group_pending_max_idle_seconds", 0, dbLabel, stream, g.Name)
+		e.registerConstMetricGauge(ch, "stream_group_pending_over_delivery_threshold", 0, dbLabel, stream, g.Name)
+		return
+	}
+
+	pending, err := getStreamGroupPendingInfo(c, stream, g.Name, e.options.CheckKeysBatchSize, e.options.StreamsDeliveryThreshold)
+	if err != nil {
+		log.Errorf("couldn't get pending entries for group '%s' of stream '%s', err: %s", g.Name, stream, err)
+		return
+	}
+
+	oldestAgeSeconds := 0.0
+	if ts := parseStreamItemId(pending.OldestEntryId); ts > 0 {
+		oldestAgeSeconds = max(float64(time.Now().UnixMilli())-ts, 0) / 1e3
+	}
+	e.registerConstMetricGauge(ch, "stream_group_pending_oldest_entry_age_seconds", oldestAgeSeconds, dbLabel, stream, g.Name)
+	e.registerConstMetricGauge(ch, "stream_group_pending_max_delivery_count", float64(pending.MaxDeliveryCount), dbLabel, stream, g.Name)
+	e.registerConstMetricGauge(ch, "stream_group_pending_max_idle_seconds", float64(pending.MaxIdle)/1e3, dbLabel, stream, g.Name)
+	e.registerConstMetricGauge(ch, "stream_group_pending_over_delivery_threshold", float64(pending.EntriesAboveDeliveries), dbLabel, stream, g.Name)
+}
@@ -1,13 +1,16 @@
 package exporter
 
 import (
+	"fmt"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -751,3 +754,217 @@ func TestClusterStreamMetricsExtraction(t *testing.T) {
 		t.Logf("HTTP endpoint successfully returned metrics without cluster MOVED errors")
 	}
 }
+
+func TestStreamsGetStreamGroupPendingInfo(t *testing.T) {
+	pel := []any{
+		[]any{[]byte("1638006862416-0"), []byte("consumer-1"), int64(5000), int64(1)},
+		[]any{[]byte("1638006862417-0"), []byte("consumer-1"), int64(7000), int64(12)},
+		[]any{[]byte("1638006862418-0"), []byte("consumer-2"), int64(1000), int64(6)},
+		[]any{[]byte("1638006862419-0"), []byte("consumer-2"), int64(500), int64(2)},
+		[]any{[]byte("1638006862420-0"), []byte("consumer-3"), int64(100), int64(5)},
+	}
+
+	var starts []string
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		if cmd != "XPENDING" || len(args) != 5 {
+			t.Fatalf("unexpected command: %s %v", cmd, args)
+		}
+		start := args[2].(string)
+		count := int(args[4].(int64))
+		starts = append(starts, start)
+
+		idx := 0
+		if start != "-" {
+			for i, e := range pel {
+				if "("+string(e.([]any)[0].([]byte)) == start {
+					idx = i + 1
+				}
+			}
+		}
+		return pel[idx:min(idx+count, len(pel))], nil
+	}}
+
+	info, err := getStreamGroupPendingInfo(c, "stream", "group", 2, 5)
+	if err != nil {
+		t.Fatalf("getStreamGroupPendingInfo() err: %s", err)
+	}
+
+	if want := []string{"-", "(1638006862417-0", "(1638006862419-0"}; strings.Join(starts, ",") != strings.Join(want, ",") {
+		t.Errorf("wrong XPENDING pages, want: %v got: %v", want, starts)
+	}
+	if info.OldestEntryId != "1638006862416-0" {
+		t.Errorf("wrong oldest entry: %s", info.OldestEntryId)
+	}
+	if info.MaxDeliveryCount != 12 {
+		t.Errorf("wrong max delivery count: %d", info.MaxDeliveryCount)
+	}
+	if info.MaxIdle != 7000 {
+		t.Errorf("wrong max idle: %d", info.MaxIdle)
+	}
+	if info.EntriesAboveDeliveries != 2 {
+		t.Errorf("wrong number of entries above threshold: %d", info.EntriesAboveDeliveries)
+	}
+}
+
+func TestStreamsGroupPendingMetrics(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", StreamsInclPendingSummary: true, StreamsDeliveryThreshold: 1, CheckKeysBatchSize: 10})
+
+	oldestId := fmt.Sprintf("%d-0", time.Now().Add(-time.Minute).UnixMilli())
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		return []any{
+			[]any{[]byte(oldestId), []byte("consumer-1"), int64(1500), int64(3)},
+		}, nil
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractStreamGroupPendingMetrics(chM, c, "db0", "stream", streamGroupsInfo{Name: "group", Pending: 1})
+		close(chM)
+	}()
+
+	got := map[string]float64{}
+	for m := range chM {
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		for _, n := range []string{"oldest_entry_age_seconds", "max_delivery_count", "max_idle_seconds", "over_delivery_threshold"} {
+			if strings.Contains(m.Desc().String(), "stream_group_pending_"+n) {
+				got[n] = d.GetGauge().GetValue()
+			}
+		}
+	}
+
+	if age := got["oldest_entry_age_seconds"]; age < 59 || age > 120 {
+		t.Errorf("unexpected oldest entry age: %f", age)
+	}
+	if got["max_delivery_count"] != 3 || got["max_idle_seconds"] != 1.5 || got["over_delivery_threshold"] != 1 {
+		t.Errorf("unexpected metrics: %v", got)
+	}
+}
+
+func TestStreamsGroupLagSeconds(t *testing.T) {
+	info := &streamInfo{LastGeneratedId: "1638006872416-0", FirstEntryId: "1638006852416-0"}
+
+	for _, tst := range []struct {
+		name  string
+		group streamGroupsInfo
+		want  float64
+	}{
+		{name: "no lag", group: streamGroupsInfo{LastDeliveredId: "1638006862416-0", Lag: 0}, want: 0},
+		{name: "10s behind", group: streamGroupsInfo{LastDeliveredId: "1638006862416-0", Lag: 3000}, want: 10},
+		{name: "nothing delivered", group: streamGroupsInfo{LastDeliveredId: "0-0", Lag: 3000}, want: 20},
+		{name: "delivered id ahead", group: streamGroupsInfo{LastDeliveredId: "1638006882416-0", Lag: 1}, want: 0},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := streamGroupLagSeconds(info, tst.group); got != tst.want {
+				t.Errorf("want: %f got: %f", tst.want, got)
+			}
+		})
+	}
+}
+
+func TestStreamsEntriesAddedPerSecond(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckSingleStreams: "db0=mystream"})
+
+	entriesAdded := int64(1000)
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch {
+		case cmd == "SELECT":
+			return "OK", nil
+		case cmd == "XINFO" && args[0] == "STREAM":
+			return []any{[]byte("length"), int64(10), []byte("entries-added"), entriesAdded, []byte("last-generated-id"), []byte("1638006862416-0")}, nil
+		case cmd == "XINFO" && args[0] == "GROUPS":
+			return []any{}, nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s %v", cmd, args)
+	}}
+
+	collect := func() map[string]float64 {
+		chM := make(chan prometheus.Metric)
+		go func() {
+			e.extractStreamMetrics(chM, c)
+			close(chM)
+		}()
+		got := map[string]float64{}
+		for m := range chM {
+			d := &dto.Metric{}
+			_ = m.Write(d)
+			for _, n := range []string{"stream_entries_added_total", "stream_entries_added_per_second"} {
+				if strings.Contains(m.Desc().String(), `"test_`+n+`"`) {
+					got[n] = d.GetCounter().GetValue() + d.GetGauge().GetValue()
+				}
+			}
+		}
+		return got
+	}
+
+	first := collect()
+	if first["stream_entries_added_total"] != 1000 {
+		t.Errorf("wrong stream_entries_added_total: %v", first)
+	}
+	if _, ok := first["stream_entries_added_per_second"]; ok {
+		t.Errorf("didn't expect a rate after the first scrape")
+	}
+
+	// pretend the previous scrape happened 10 seconds ago
+	k := dbKeyPair{db: "0", key: "mystream"}
+	prev := e.streamProductionSamples[k]
+	prev.ts = prev.ts.Add(-10 * time.Second)
+	e.streamProductionSamples[k] = prev
+	entriesAdded = 1500
+
+	second := collect()
+	if rate := second["stream_entries_added_per_second"]; rate < 49 || rate > 50.1 {
+		t.Errorf("unexpected rate: %f", rate)
+	}
+
+	// the exporters of /scrape requests don't keep the samples of the previous scrape
+	e.options.StreamsExcludeProductionRate = true
+	collect()
+	entriesAdded = 2000
+	if _, ok := collect()["stream_entries_added_per_second"]; ok || len(e.streamProductionSamples) != 0 {
+		t.Errorf("didn't expect a rate with StreamsExcludeProductionRate")
+	}
+}
+
+func TestStreamsGroupLagSecondsUnknownLag(t *testing.T) {
+	e, _ := NewRedisExporter("redis://localhost:6379", Options{Namespace: "test", CheckSingleStreams: "db0=mystream"})
+
+	c := &fakeConn{do: func(cmd string, args ...any) (any, error) {
+		switch {
+		case cmd == "SELECT":
+			return "OK", nil
+		case cmd == "XINFO" && args[0] == "STREAM":
+			return []any{[]byte("length"), int64(10), []byte("last-generated-id"), []byte("1638006872416-0"), []byte("first-entry"), nil}, nil
+		case cmd == "XINFO" && args[0] == "GROUPS":
+			return []any{
+				[]any{[]byte("name"), []byte("known"), []byte("last-delivered-id"), []byte("1638006862416-0"), []byte("lag"), int64(5)},
+				[]any{[]byte("name"), []byte("unknown"), []byte("last-delivered-id"), []byte("1638006862416-0"), []byte("lag"), nil},
+			}, nil
+		case cmd == "XINFO" && args[0] == "CONSUMERS":
+			return []any{}, nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s %v", cmd, args)
+	}}
+
+	chM := make(chan prometheus.Metric)
+	go func() {
+		e.extractStreamMetrics(chM, c)
+		close(chM)
+	}()
+	got := map[string]float64{}
+	for m := range chM {
+		if !strings.Contains(m.Desc().String(), `"test_stream_group_lag_seconds"`) {
+			continue
+		}
+		d := &dto.Metric{}
+		_ = m.Write(d)
+		for _, l := range d.GetLabel() {
+			if l.GetName() == "group" {
+				got[l.GetValue()] = d.GetGauge().GetValue()
+			}
+		}
+	}
+	if len(got) != 1 || got["known"] != 10 {
+		t.Errorf("expected the lag in seconds of the known group only, got: %v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	subscriberMinBackoff = time.Second
+	subscriberMaxBackoff = 30 * time.Second
+)
+
+// Stop terminates all background goroutines (e.g. pub/sub subscribers) started by the exporter.
+func (e *Exporter) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopCh)
+	})
+}
+
+// subscribe keeps a pub/sub connection to the exporter's redis instance open until Stop() is called
+// and hands every received message to handle(). Connection errors are retried with an exponential backoff.
+// When usePatterns is set the channels are subscribed to with PSUBSCRIBE.
+func (e *Exporter) subscribe(name string, channels []string, usePatterns bool, handle func(channel string, data []byte)) {
+	backoff := subscriberMinBackoff
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		default:
+		}
+
+		received, err := e.subscribeOnce(channels, usePatterns, handle)
+		if received {
+			backoff = subscriberMinBackoff
+		}
+
+		select {
+		case <-e.stopCh:
+			log.Debugf("%s subscriber stopped", name)
+			return
+		default:
+		}
+
+		log.Errorf("%s subscriber err: %s, reconnecting in %s", name, err, backoff)
+		select {
+		case <-e.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, subscriberMaxBackoff)
+	}
+}
+
+func (e *Exporter) subscribeOnce(channels []string, usePatterns bool, handle func(channel string, data []byte)) (received bool, err error) {
+	c, err := e.connectToRedis()
+	if err != nil {
+		return false, err
+	}
+	psc := redis.PubSubConn{Conn: c}
+	defer psc.Close()
+
+	// closing the connection is the only way to interrupt a blocking Receive()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-e.stopCh:
+			psc.Close()
+		case <-done:
+		}
+	}()
+
+	args := make([]any, len(channels))
+	for i, ch := range channels {
+		args[i] = ch
+	}
+	if usePatterns {
+		err = psc.PSubscribe(args...)
+	} else {
+		err = psc.Subscribe(args...)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		// a zero timeout disables the read timeout set by the dial options, pub/sub connections can be idle for a long time
+		switch v := psc.ReceiveWithTimeout(0).(type) {
+		case redis.Message:
+			received = true
+			handle(v.Channel, v.Data)
+		case redis.Subscription:
+			log.Debugf("subscription: %s %s %d", v.Kind, v.Channel, v.Count)
+		case redis.Pong:
+		case error:
+			return received, v
+		default:
+			return received, fmt.Errorf("unexpected pub/sub reply: %#v", v)
+		}
+	}
+}
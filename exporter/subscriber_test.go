@@ -0,0 +1,31 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeStop(t *testing.T) {
+	// nothing is listening on this port so the subscriber keeps retrying until it's stopped
+	e, err := NewRedisExporter("redis://127.0.0.1:1", Options{Namespace: "test", ConnectionTimeouts: time.Second})
+	if err != nil {
+		t.Fatalf("NewRedisExporter: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.subscribe("test", []string{"chan"}, false, func(string, []byte) {})
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	e.Stop()
+	// calling Stop() twice must not panic
+	e.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscribe() didn't return after Stop()")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -163,7 +165,68 @@ func createPrometheusRegistry(redisMetricsOnly, inclGoRuntimeMetrics bool) *prom
 	return registry
 }
 
+// analyzeRdb runs the analyze-rdb subcommand: it analyzes an RDB file (or stdin with "-") without a redis instance
+func analyzeRdb(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("analyze-rdb", flag.ContinueOnError)
+	var (
+		format                      = fs.String("format", "prometheus", "Output format, valid options are prometheus and json")
+		namespace                   = fs.String("namespace", getEnv("REDIS_EXPORTER_NAMESPACE", "redis"), "Namespace for metrics")
+		checkKeyGroups              = fs.String("check-key-groups", getEnv("REDIS_EXPORTER_CHECK_KEY_GROUPS", ""), "Comma separated list of lua regex for grouping keys")
+		checkKeyGroupsAutoDepth     = fs.Int64("check-key-groups-auto-depth", getEnvInt64("REDIS_EXPORTER_CHECK_KEY_GROUPS_AUTO_DEPTH", 0), "Group keys that don't match any of the check-key-groups regexes by their first N delimiter separated segments, 0 disables automatic grouping")
+		checkKeyGroupsAutoDelimiter = fs.String("check-key-groups-auto-delimiter", getEnv("REDIS_EXPORTER_CHECK_KEY_GROUPS_AUTO_DELIMITER", ":"), "Delimiter of the key segments for check-key-groups-auto-depth")
+		maxDistinctKeyGroups        = fs.Int64("max-distinct-key-groups", getEnvInt64("REDIS_EXPORTER_MAX_DISTINCT_KEY_GROUPS", 100), "The maximum number of distinct key groups with the biggest serialized size per database, the leftover key groups will be aggregated in the 'overflow' bucket")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s analyze-rdb [flags] <rdb file>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected the path of the RDB file")
+	}
+	if *format != "prometheus" && *format != "json" {
+		return errors.New("invalid format: " + *format)
+	}
+
+	var rdb io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rdb = f
+	}
+
+	analysis, err := exporter.AnalyzeRDB(rdb, exporter.Options{
+		CheckKeyGroups:         *checkKeyGroups,
+		KeyGroupsAutoDepth:     *checkKeyGroupsAutoDepth,
+		KeyGroupsAutoDelimiter: *checkKeyGroupsAutoDelimiter,
+		MaxDistinctKeyGroups:   *maxDistinctKeyGroups,
+	})
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		return analysis.WriteJSON(stdout)
+	}
+	return analysis.WritePrometheus(stdout, *namespace)
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "analyze-rdb" {
+		if err := analyzeRdb(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	var (
 		redisAddr                       = flag.String("redis.addr", getEnv("REDIS_ADDR", "redis://localhost:6379"), "Address of the Redis instance to scrape")
 		redisUser                       = flag.String("redis.user", getEnv("REDIS_USER", ""), "User name to use for authentication (Redis ACL for Redis 6.0 and newer)")
@@ -173,10 +236,18 @@ func main() {
 		checkKeys                       = flag.String("check-keys", getEnv("REDIS_EXPORTER_CHECK_KEYS", ""), "Comma separated list of key-patterns to export value and length/size, searched for with SCAN")
 		checkSingleKeys                 = flag.String("check-single-keys", getEnv("REDIS_EXPORTER_CHECK_SINGLE_KEYS", ""), "Comma separated list of single keys to export value and length/size")
 		checkKeyGroups                  = flag.String("check-key-groups", getEnv("REDIS_EXPORTER_CHECK_KEY_GROUPS", ""), "Comma separated list of lua regex for grouping keys")
+		checkKeyDistributions           = flag.String("check-key-distributions", getEnv("REDIS_EXPORTER_CHECK_KEY_DISTRIBUTIONS", ""), "Comma separated list of key-patterns to export size and memory usage histograms per key type for, searched for with SCAN ... TYPE")
+		checkKeyDistributionTypes       = flag.String("check-key-distribution-types", getEnv("REDIS_EXPORTER_CHECK_KEY_DISTRIBUTION_TYPES", ""), "Comma separated list of key types to scan for with check-key-distributions, defaults to all of string,list,set,zset,hash,stream")
+		checkBigKeysBudget              = flag.Int64("check-big-keys-budget", getEnvInt64("REDIS_EXPORTER_CHECK_BIG_KEYS_BUDGET", 0), "Number of keys to examine per scrape to find the biggest keys (MEMORY USAGE) and, with an LFU maxmemory-policy, the most frequently accessed keys (OBJECT FREQ). The SCAN cursor is resumed with the next scrape, 0 disables the big keys scan")
+		checkBigKeysTopN                = flag.Int64("check-big-keys-top-n", getEnvInt64("REDIS_EXPORTER_CHECK_BIG_KEYS_TOP_N", 10), "Number of keys to export per database for check-big-keys-budget")
 		checkStreams                    = flag.String("check-streams", getEnv("REDIS_EXPORTER_CHECK_STREAMS", ""), "Comma separated list of stream-patterns to export info about streams, groups and consumers, searched for with SCAN")
 		checkSingleStreams              = flag.String("check-single-streams", getEnv("REDIS_EXPORTER_CHECK_SINGLE_STREAMS", ""), "Comma separated list of single streams to export info about streams, groups and consumers")
 		streamsExcludeConsumerMetrics   = flag.Bool("streams-exclude-consumer-metrics", getEnvBool("REDIS_EXPORTER_STREAMS_EXCLUDE_CONSUMER_METRICS", false), "Don't collect per consumer metrics for streams (decreases cardinality)")
+		streamsExcludeProductionRate    = flag.Bool("streams-exclude-production-rate", getEnvBool("REDIS_EXPORTER_STREAMS_EXCLUDE_PRODUCTION_RATE", false), "Don't export stream_entries_added_per_second, which is derived from the previous scrape and thus not available via the /scrape endpoint")
+		streamsInclPendingSummary       = flag.Bool("streams-include-pending-summary", getEnvBool("REDIS_EXPORTER_STREAMS_INCL_PENDING_SUMMARY", false), "Whether to run XPENDING for every stream group to export the age of the oldest pending message and delivery counts (pages through the whole PEL)")
+		streamsDeliveryThreshold        = flag.Int64("streams-pending-delivery-threshold", getEnvInt64("REDIS_EXPORTER_STREAMS_PENDING_DELIVERY_THRESHOLD", 5), "Pending stream messages delivered more often than this are counted in stream_group_pending_over_delivery_threshold")
 		countKeys                       = flag.String("count-keys", getEnv("REDIS_EXPORTER_COUNT_KEYS", ""), "Comma separated list of patterns to count (eg: 'db0=production_*,db3=sessions:*'), searched for with SCAN")
+		countKeysInclTTL                = flag.Bool("count-keys-include-ttl", getEnvBool("REDIS_EXPORTER_COUNT_KEYS_INCL_TTL", false), "Whether to run PTTL for the keys matching count-keys to export a histogram of their remaining TTL and the number of keys without an expiry")
 		checkKeysBatchSize              = flag.Int64("check-keys-batch-size", getEnvInt64("REDIS_EXPORTER_CHECK_KEYS_BATCH_SIZE", 1000), "Approximate number of keys to process in each execution, larger value speeds up scanning.\nWARNING: Still Redis is a single-threaded app, huge COUNT can affect production environment.")
 		scriptPath                      = flag.String("script", getEnv("REDIS_EXPORTER_SCRIPT", ""), "Comma separated list of path(s) to Redis Lua script(s) for gathering extra metrics")
 		luaScriptReadOnly               = flag.Bool("lua-script-read-only", getEnvBool("REDIS_EXPORTER_LUA_SCRIPT_READ_ONLY", false), "Use EVAL_RO command for gathering extra metrics with Lua script")
@@ -191,6 +262,11 @@ func main() {
 		tlsServerCertFile               = flag.String("tls-server-cert-file", getEnv("REDIS_EXPORTER_TLS_SERVER_CERT_FILE", ""), "Name of the server certificate file (including full path) if the web interface and telemetry should use TLS")
 		tlsServerCaCertFile             = flag.String("tls-server-ca-cert-file", getEnv("REDIS_EXPORTER_TLS_SERVER_CA_CERT_FILE", ""), "Name of the CA certificate file (including full path) if the web interface and telemetry should require TLS client authentication")
 		tlsServerMinVersion             = flag.String("tls-server-min-version", getEnv("REDIS_EXPORTER_TLS_SERVER_MIN_VERSION", "TLS1.2"), "Minimum TLS version that is acceptable by the web interface and telemetry when using TLS")
+		checkKeyGroupsScanInterval      = flag.Duration("check-key-groups-scan-interval", getEnvDuration("REDIS_EXPORTER_CHECK_KEY_GROUPS_SCAN_INTERVAL", 0), "Interval of the incremental background scan for check-key-groups, 0 scans the whole keyspace on every scrape")
+		checkKeyGroupsScanKeysPerTick   = flag.Int64("check-key-groups-scan-keys-per-tick", getEnvInt64("REDIS_EXPORTER_CHECK_KEY_GROUPS_SCAN_KEYS_PER_TICK", 10000), "Maximum number of keys examined per interval of the background scan for check-key-groups")
+		checkKeyGroupsAutoDepth         = flag.Int64("check-key-groups-auto-depth", getEnvInt64("REDIS_EXPORTER_CHECK_KEY_GROUPS_AUTO_DEPTH", 0), "Group keys that don't match any of the check-key-groups regexes by their first N delimiter separated segments, with numeric, UUID and hex segments replaced by placeholders, 0 disables automatic grouping")
+		checkKeyGroupsAutoDelimiter     = flag.String("check-key-groups-auto-delimiter", getEnv("REDIS_EXPORTER_CHECK_KEY_GROUPS_AUTO_DELIMITER", ":"), "Delimiter of the key segments for check-key-groups-auto-depth")
+		keyspaceEvents                  = flag.String("keyspace-events", getEnv("REDIS_EXPORTER_KEYSPACE_EVENTS", ""), "Comma separated list of keyspace notification events (eg: 'expired,evicted,set,del') to subscribe to and count per db and key group, requires notify-keyspace-events to be enabled on the redis instance")
 		maxDistinctKeyGroups            = flag.Int64("max-distinct-key-groups", getEnvInt64("REDIS_EXPORTER_MAX_DISTINCT_KEY_GROUPS", 100), "The maximum number of distinct key groups with the most memory utilization to present as distinct metrics per database, the leftover key groups will be aggregated in the 'overflow' bucket")
 		isDebug                         = flag.Bool("debug", getEnvBool("REDIS_EXPORTER_DEBUG", false), "Output verbose debug information (sets log level to DEBUG, takes precedence over \"--log-level\")")
 		logLevel                        = flag.String("log-level", getEnv("REDIS_EXPORTER_LOG_LEVEL", "INFO"), "Set log level")
@@ -201,19 +277,32 @@ func main() {
 		clusterDiscoverHostnames        = flag.Bool("cluster-discover-hostnames", getEnvBool("REDIS_EXPORTER_CLUSTER_DISCOVER_HOSTNAMES", false), "Whether to use hostname for cluster node discovery if available via `/discover-cluster-nodes` endpoint.")
 		exportClientList                = flag.Bool("export-client-list", getEnvBool("REDIS_EXPORTER_EXPORT_CLIENT_LIST", false), "Whether to scrape Client List specific metrics")
 		exportClientPort                = flag.Bool("export-client-port", getEnvBool("REDIS_EXPORTER_EXPORT_CLIENT_PORT", false), "Whether to include the client's port when exporting the client list. Warning: including the port increases the number of metrics generated and will make your Prometheus server take up more memory")
+		clientListAggregateBy           = flag.String("export-client-list-aggregate-by", getEnv("REDIS_EXPORTER_EXPORT_CLIENT_LIST_AGGREGATE_BY", ""), "Comma separated list of dimensions (name, user, lib-name, lib-ver, flags, db, ip or ip/<prefix length>) to export the clients of CLIENT LIST grouped by")
+		exportClientChurn               = flag.Bool("export-client-churn", getEnvBool("REDIS_EXPORTER_EXPORT_CLIENT_CHURN", false), "Whether to export the number of connections opened and closed between scrapes per lib-name, user and client name, plus the distribution of the connection lifetimes")
 		showVersion                     = flag.Bool("version", false, "Show version information and exit")
 		redisMetricsOnly                = flag.Bool("redis-only-metrics", getEnvBool("REDIS_EXPORTER_REDIS_ONLY_METRICS", false), "Whether to export only Redis metrics (omit Go process+runtime metrics)")
 		inclGoRuntimeMetrics            = flag.Bool("include-go-runtime-metrics", getEnvBool("REDIS_EXPORTER_INCLUDE_GO_RUNTIME_METRICS", false), "Whether to include Go runtime metrics")
 		pingOnConnect                   = flag.Bool("ping-on-connect", getEnvBool("REDIS_EXPORTER_PING_ON_CONNECT", false), "Whether to ping the redis instance after connecting")
 		inclConfigMetrics               = flag.Bool("include-config-metrics", getEnvBool("REDIS_EXPORTER_INCL_CONFIG_METRICS", false), "Whether to include all config settings as metrics")
 		inclModulesMetrics              = flag.Bool("include-modules-metrics", getEnvBool("REDIS_EXPORTER_INCL_MODULES_METRICS", false), "Whether to collect Redis Modules metrics")
+		inclReplicationTopology         = flag.Bool("include-replication-topology", getEnvBool("REDIS_EXPORTER_INCL_REPLICATION_TOPOLOGY", false), "Whether to export the replication chain depth (following master_host) and the lag of the replicas and to enable the /replication-topology endpoint")
+		inclMemoryStats                 = flag.Bool("include-memory-stats", getEnvBool("REDIS_EXPORTER_INCL_MEMORY_STATS", false), "Whether to collect MEMORY STATS (incl. the per-db hashtable overhead) and MEMORY DOCTOR metrics")
+		inclAclMetrics                  = flag.Bool("include-acl-metrics", getEnvBool("REDIS_EXPORTER_INCL_ACL_METRICS", false), "Whether to export the ACL LOG entries (denied commands, keys, channels and failed authentications, not via the /scrape endpoint) and the users of ACL LIST")
+		inclCommandRollups              = flag.Bool("include-command-rollups", getEnvBool("REDIS_EXPORTER_INCL_COMMAND_ROLLUPS", false), "Whether to export the command stats aggregated by ACL category, command group and command flag (uses COMMAND INFO and COMMAND DOCS)")
+		inclMallocStats                 = flag.Bool("include-malloc-stats", getEnvBool("REDIS_EXPORTER_INCL_MALLOC_STATS", false), "Whether to parse MEMORY MALLOC-STATS for jemalloc metrics incl. the fragmentation per bin/size class")
 		inclAofFileSize                 = flag.Bool("include-aof-file-size", getEnvBool("REDIS_EXPORTER_INCL_AOF_FILE_SIZE", false), "Whether to collect AOF file size metrics")
 		overrideAofFilePath             = flag.String("override-aof-file-path", getEnv("REDIS_EXPORTER_OVERRIDE_AOF_FILE_PATH", ""), "Override the AOF file path in the metrics")
+		inclPersistenceFiles            = flag.Bool("include-persistence-files", getEnvBool("REDIS_EXPORTER_INCL_PERSISTENCE_FILES", false), "Whether to collect metrics of the AOF manifest, the RDB file and the free disk space of the persistence dir (the exporter needs access to the dir of redis)")
+		overridePersistenceDir          = flag.String("override-persistence-dir", getEnv("REDIS_EXPORTER_OVERRIDE_PERSISTENCE_DIR", ""), "Override the path of the dir of redis, e.g. when it's mounted at a different path for the exporter")
 		inclSearchIndexesMetrics        = flag.Bool("include-search-indexes-metrics", getEnvBool("REDIS_EXPORTER_INCL_SEARCH_INDEXES_METRICS", false), "Whether to collect Redis Search indexes metrics")
 		inclSentinelPeerInfo            = flag.Bool("include-sentinel-peer-info", getEnvBool("REDIS_EXPORTER_INCL_SENTINEL_PEER_INFO", false), "Whether to export sentinel_peer_info metrics (high cardinality)")
+		sentinelSubscribeEvents         = flag.Bool("sentinel-subscribe-events", getEnvBool("REDIS_EXPORTER_SENTINEL_SUBSCRIBE_EVENTS", false), "Whether to subscribe to Sentinel's +switch-master, +sdown and +odown channels in the background and count the events per master")
 		checkSearchIndexes              = flag.String("check-search-indexes", getEnv("REDIS_EXPORTER_CHECK_SEARCH_INDEXES", ".*"), "Regex pattern for Redis Search indexes to export metrics from FT.INFO command")
 		disableExportingKeyValues       = flag.Bool("disable-exporting-key-values", getEnvBool("REDIS_EXPORTER_DISABLE_EXPORTING_KEY_VALUES", false), "Whether to disable values of keys stored in redis as labels or not when using check-keys/check-single-key")
 		excludeLatencyHistogramMetrics  = flag.Bool("exclude-latency-histogram-metrics", getEnvBool("REDIS_EXPORTER_EXCLUDE_LATENCY_HISTOGRAM_METRICS", false), "Do not try to collect latency histogram metrics")
+		inclLatencyHistory              = flag.Bool("include-latency-history", getEnvBool("REDIS_EXPORTER_INCL_LATENCY_HISTORY", false), "Whether to ingest LATENCY HISTORY of the latency monitor events into a spike histogram per event")
+		inclNativeLatencyHistograms     = flag.Bool("include-native-latency-histograms", getEnvBool("REDIS_EXPORTER_INCL_NATIVE_LATENCY_HISTOGRAMS", false), "Whether to add native histogram buckets, built from the power-of-two buckets of LATENCY HISTOGRAM, to commands_latencies_usec (served when the protobuf format is negotiated)")
+		inclSlowlogExemplars            = flag.Bool("include-slowlog-exemplars", getEnvBool("REDIS_EXPORTER_INCL_SLOWLOG_EXEMPLARS", false), "Whether to attach the slowest call per command of the slowlog as exemplar to commands_latencies_usec, enables the OpenMetrics format")
 		redactConfigMetrics             = flag.Bool("redact-config-metrics", getEnvBool("REDIS_EXPORTER_REDACT_CONFIG_METRICS", true), "Whether to redact config settings that include potentially sensitive information like passwords")
 		inclSystemMetrics               = flag.Bool("include-system-metrics", getEnvBool("REDIS_EXPORTER_INCL_SYSTEM_METRICS", false), "Whether to include system metrics like e.g. redis_total_system_memory_bytes")
 		skipTLSVerification             = flag.Bool("skip-tls-verification", getEnvBool("REDIS_EXPORTER_SKIP_TLS_VERIFICATION", false), "Whether to to skip TLS verification")
@@ -229,6 +318,17 @@ func main() {
 		excludeFalkorDBGraphMemoryAttrs = flag.Bool("exclude-falkordb-graph-memory-attrs", getEnvBool("REDIS_EXPORTER_EXCLUDE_FALKORDB_GRAPH_MEMORY_ATTRS", false), "Whether to skip FalkorDB per-label and per-relationship-type graph memory metrics")
 		maxFalkorDBGraphMemoryGraphs    = flag.Int64("falkordb-graph-memory-max-graphs", getEnvInt64("REDIS_EXPORTER_FALKORDB_GRAPH_MEMORY_MAX_GRAPHS", 10000), "Maximum number of graphs to collect FalkorDB GRAPH.MEMORY metrics for, set to -1 for no limit")
 		falkorDBGraphMemoryCacheTTL     = flag.Duration("falkordb-graph-memory-cache-ttl", getEnvDuration("REDIS_EXPORTER_FALKORDB_GRAPH_MEMORY_CACHE_TTL", 60*time.Second), "TTL for caching FalkorDB GRAPH.MEMORY results, set to 0 to disable caching")
+		otlpEndpoint                    = flag.String("otlp-endpoint", getEnv("REDIS_EXPORTER_OTLP_ENDPOINT", ""), "URL of the OTLP endpoint (eg: 'http://otel-collector:4317') to push the metrics to, in addition to serving them at the telemetry path")
+		otlpProtocol                    = flag.String("otlp-protocol", getEnv("REDIS_EXPORTER_OTLP_PROTOCOL", "grpc"), "Protocol of the OTLP endpoint, valid options are grpc and http/protobuf")
+		otlpPushInterval                = flag.Duration("otlp-push-interval", getEnvDuration("REDIS_EXPORTER_OTLP_PUSH_INTERVAL", 30*time.Second), "Interval of pushing the metrics to the OTLP endpoint")
+		remoteWriteURL                  = flag.String("remote-write-url", getEnv("REDIS_EXPORTER_REMOTE_WRITE_URL", ""), "URL of a Prometheus remote-write endpoint (eg: 'http://prometheus:9090/api/v1/write') to push the metrics to, in addition to serving them at the telemetry path")
+		remoteWritePushInterval         = flag.Duration("remote-write-push-interval", getEnvDuration("REDIS_EXPORTER_REMOTE_WRITE_PUSH_INTERVAL", 30*time.Second), "Interval of pushing the metrics to the remote-write endpoint, also the request timeout (at least 10s)")
+		remoteWriteQueueSize            = flag.Int64("remote-write-queue-size", getEnvInt64("REDIS_EXPORTER_REMOTE_WRITE_QUEUE_SIZE", 100), "Maximum number of pushes kept in memory while the remote-write endpoint is unreachable, the oldest ones are dropped first")
+		remoteWriteExtraLabels          = flag.String("remote-write-extra-labels", getEnv("REDIS_EXPORTER_REMOTE_WRITE_EXTRA_LABELS", ""), "Comma separated list of labels (eg: 'env=prod,region=eu') added to all pushed samples, the instance label defaults to the redis address")
+		statsdAddress                   = flag.String("statsd-address", getEnv("REDIS_EXPORTER_STATSD_ADDRESS", ""), "Address (host:port) of a StatsD server to push the metrics to via UDP, labels that aren't part of the sink-metric-name-template are sent as DogStatsD tags")
+		graphiteAddress                 = flag.String("graphite-address", getEnv("REDIS_EXPORTER_GRAPHITE_ADDRESS", ""), "Address (host:port) of a Graphite server to push the metrics to via the plaintext protocol, labels that aren't part of the sink-metric-name-template are sent as Graphite tags")
+		sinkPushInterval                = flag.Duration("sink-push-interval", getEnvDuration("REDIS_EXPORTER_SINK_PUSH_INTERVAL", 30*time.Second), "Interval of pushing the metrics to StatsD and Graphite")
+		sinkMetricNameTemplate          = flag.String("sink-metric-name-template", getEnv("REDIS_EXPORTER_SINK_METRIC_NAME_TEMPLATE", "{name}.{db}.{cmd}.{graph}"), "Template of the StatsD and Graphite metric names, {name} is the metric name and {<label>} the value of a label")
 		appendInstanceRoleLabel         = flag.Bool("append-instance-role-label", getEnvBool("REDIS_EXPORTER_APPEND_INSTANCE_ROLE_LABEL", false), "Whether to append 'instance_role' label to redis metrics")
 	)
 	flag.Parse()
@@ -286,31 +386,57 @@ func main() {
 			CheckSingleKeys:                *checkSingleKeys,
 			CheckKeysBatchSize:             *checkKeysBatchSize,
 			CheckKeyGroups:                 *checkKeyGroups,
+			CheckKeyDistributions:          *checkKeyDistributions,
+			CheckKeyDistributionTypes:      *checkKeyDistributionTypes,
+			CheckBigKeysBudget:             *checkBigKeysBudget,
+			CheckBigKeysTopN:               *checkBigKeysTopN,
 			MaxDistinctKeyGroups:           *maxDistinctKeyGroups,
+			KeyGroupsScanInterval:          *checkKeyGroupsScanInterval,
+			KeyGroupsScanKeysPerTick:       *checkKeyGroupsScanKeysPerTick,
+			KeyGroupsAutoDelimiter:         *checkKeyGroupsAutoDelimiter,
+			KeyGroupsAutoDepth:             *checkKeyGroupsAutoDepth,
+			KeyspaceEvents:                 *keyspaceEvents,
 			CheckStreams:                   *checkStreams,
 			CheckSingleStreams:             *checkSingleStreams,
 			StreamsExcludeConsumerMetrics:  *streamsExcludeConsumerMetrics,
+			StreamsExcludeProductionRate:   *streamsExcludeProductionRate,
+			StreamsInclPendingSummary:      *streamsInclPendingSummary,
+			StreamsDeliveryThreshold:       *streamsDeliveryThreshold,
 			CountKeys:                      *countKeys,
+			CountKeysInclTTL:               *countKeysInclTTL,
 			LuaScript:                      ls,
 			LuaScriptReadOnly:              *luaScriptReadOnly,
 			InclSystemMetrics:              *inclSystemMetrics,
 			InclConfigMetrics:              *inclConfigMetrics,
 			DisableExportingKeyValues:      *disableExportingKeyValues,
 			ExcludeLatencyHistogramMetrics: *excludeLatencyHistogramMetrics,
+			InclLatencyHistory:             *inclLatencyHistory,
+			InclNativeLatencyHistograms:    *inclNativeLatencyHistograms,
+			InclSlowlogExemplars:           *inclSlowlogExemplars,
 			RedactConfigMetrics:            *redactConfigMetrics,
 			SetClientName:                  *setClientName,
 			IsTile38:                       *isTile38,
 			IsCluster:                      *isCluster,
 			ClusterDiscoverHostnames:       *clusterDiscoverHostnames,
 			InclModulesMetrics:             *inclModulesMetrics,
+			InclMemoryStats:                *inclMemoryStats,
+			InclMallocStats:                *inclMallocStats,
+			InclCommandRollups:             *inclCommandRollups,
+			InclAclMetrics:                 *inclAclMetrics,
 			InclAofFileSize:                *inclAofFileSize,
 			SlowlogHistoryEnabled:          *slowlogHistoryEnabled,
 			OverrideAofFilePath:            *overrideAofFilePath,
+			InclPersistenceFiles:           *inclPersistenceFiles,
+			OverridePersistenceDir:         *overridePersistenceDir,
 			InclSearchIndexesMetrics:       *inclSearchIndexesMetrics,
 			InclSentinelPeerInfo:           *inclSentinelPeerInfo,
+			SentinelSubscribeEvents:        *sentinelSubscribeEvents,
 			CheckSearchIndexes:             *checkSearchIndexes,
 			ExportClientList:               *exportClientList,
 			ExportClientsInclPort:          *exportClientPort,
+			ClientListAggregateBy:          *clientListAggregateBy,
+			ExportClientChurn:              *exportClientChurn,
+			InclReplicationTopology:        *inclReplicationTopology,
 			SkipCheckKeysForRoleMaster:     *skipCheckKeysForRoleMaster,
 			SkipTLSVerification:            *skipTLSVerification,
 			ClientCertFile:                 *tlsClientCertFile,
@@ -338,6 +464,17 @@ func main() {
 			MaxFalkorDBGraphMemoryGraphs:    *maxFalkorDBGraphMemoryGraphs,
 			FalkorDBGraphMemoryCacheTTL:     *falkorDBGraphMemoryCacheTTL,
 			AppendInstanceRoleLabel:         *appendInstanceRoleLabel,
+			OtlpEndpoint:                    *otlpEndpoint,
+			OtlpProtocol:                    *otlpProtocol,
+			OtlpPushInterval:                *otlpPushInterval,
+			RemoteWriteURL:                  *remoteWriteURL,
+			RemoteWritePushInterval:         *remoteWritePushInterval,
+			RemoteWriteQueueSize:            *remoteWriteQueueSize,
+			RemoteWriteExtraLabels:          *remoteWriteExtraLabels,
+			StatsdAddress:                   *statsdAddress,
+			GraphiteAddress:                 *graphiteAddress,
+			SinkPushInterval:                *sinkPushInterval,
+			SinkMetricNameTemplate:          *sinkMetricNameTemplate,
 		},
 	)
 	if err != nil {
@@ -395,5 +532,6 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
+	exp.Stop()
 	log.Infof("Server shut down gracefully")
 }
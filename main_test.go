@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -620,3 +621,34 @@ func TestValidateAuthParams(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzeRdb(t *testing.T) {
+	// db0 with the string key user:1 and the value v
+	rdb := append([]byte("REDIS0011\xfe\x00\x00\x06user:1\x01v\xff"), make([]byte, 8)...)
+	rdbFile := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(rdbFile, rdb, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := analyzeRdb([]string{"-check-key-groups", "^(user):", rdbFile}, &out); err != nil {
+		t.Fatalf("analyzeRdb() err: %s", err)
+	}
+	if !strings.Contains(out.String(), `redis_rdb_key_group_keys{db="db0",key_group="user"} 1`) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := analyzeRdb([]string{"-format", "json", rdbFile}, &out); err != nil {
+		t.Fatalf("analyzeRdb() err: %s", err)
+	}
+	if !strings.Contains(out.String(), `"rdb_version": 11`) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{{}, {"-format", "xml", rdbFile}, {filepath.Join(t.TempDir(), "missing.rdb")}} {
+		if err := analyzeRdb(args, &out); err == nil {
+			t.Errorf("expected an error for args %v", args)
+		}
+	}
+}